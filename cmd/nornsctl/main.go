@@ -5,87 +5,157 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/hypebeast/go-osc/osc"
 	"github.com/mum4k/termdash"
 	"github.com/mum4k/termdash/cell"
 	"github.com/mum4k/termdash/container"
+	"github.com/mum4k/termdash/keyboard"
 	"github.com/mum4k/termdash/linestyle"
+	"github.com/mum4k/termdash/mouse"
+	"github.com/mum4k/termdash/private/canvas"
 	"github.com/mum4k/termdash/terminal/tcell"
 	"github.com/mum4k/termdash/terminal/terminalapi"
-	"github.com/mum4k/termdash/widgets/button"
-	"github.com/mum4k/termdash/widgets/segmentdisplay"
+	"github.com/mum4k/termdash/widgetapi"
 	"github.com/zzsnzmn/osctl/internal/encoder"
+	"github.com/zzsnzmn/osctl/internal/key"
+	"github.com/zzsnzmn/osctl/internal/readout"
+	"github.com/zzsnzmn/osctl/internal/state"
+	"github.com/zzsnzmn/osctl/internal/transport"
 )
 
-// drawEncoder continuously changes the displayed percent value on the encoder by the
-// step once every delay. Exits when the context expires.
-func drawEncoder(ctx context.Context, d *encoder.Encoder, start, step int, delay time.Duration) {
-	progress := start
-	mult := 1
+func enc(tr transport.Transport, oscRoute string, encoderLabel string, extra ...encoder.Option) *encoder.Encoder {
+	opts := []encoder.Option{
+		encoder.CellOpts(cell.FgColor(cell.ColorGreen)),
+		encoder.Label(encoderLabel, cell.FgColor(cell.ColorGreen)),
+		encoder.HideTextProgress(),
+		encoder.IndicatorPointer(),
+		encoder.Transport(tr),
+		encoder.OscRoute(oscRoute),
+	}
+	e, err := encoder.New(append(opts, extra...)...)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
 
-	ticker := time.NewTicker(delay)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
+// newKey returns a key that sends 1 on press and 0 on release to the OSC
+// route and keeps whether it is held in the model.
+func newKey(tr transport.Transport, m *state.Model, oscRoute string, keyLabel string, extra ...key.Option) *key.Key {
+	opts := []key.Option{
+		key.Label(keyLabel),
+		key.PressedCellOpts(cell.FgColor(cell.ColorBlack), cell.BgColor(cell.ColorGreen)),
+		key.Transport(tr),
+		key.OscRoute(oscRoute),
+		key.OnChange(func(pressed bool) error {
+			m.SetHeld(oscRoute, pressed)
+			return nil
+		}),
+	}
+	k, err := key.New(append(opts, extra...)...)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
 
-			progress += step * mult
-			if progress > 100 || 100-progress < step {
-				progress = 100
-			} else if progress < 0 || progress < step {
-				progress = 0
-			}
+// bindFlags collects the -bind flags.
+type bindFlags []string
 
-			if progress == 100 {
-				mult = -1
-			} else if progress == 0 {
-				mult = 1
-			}
-			if err := d.Percent(progress); err != nil {
-				panic(err)
-			}
+// String implements flag.Value.String.
+func (b *bindFlags) String() string {
+	return strings.Join(*b, "; ")
+}
 
-		case <-ctx.Done():
-			return
-		}
+// Set implements flag.Value.Set.
+func (b *bindFlags) Set(v string) error {
+	*b = append(*b, v)
+	return nil
+}
+
+// defaultBinds are used when no -bind flag is provided, a right click on K2
+// long-presses it and on K3 plays the chord K1+K3.
+var defaultBinds = bindFlags{
+	"right2=2d 2u+1s",
+	"right3=1d 3d+50ms 3u+100ms 1u+50ms",
+}
+
+// mergeDeltas adds up encoder deltas waiting to be sent and keeps key presses
+// and releases apart.
+func mergeDeltas(queued, next *osc.Message) (*osc.Message, bool) {
+	if !strings.HasPrefix(next.Address, "/remote/enc/") {
+		return nil, false
 	}
+	return transport.SumDeltas(queued, next)
 }
 
-func enc(oscAddr string, oscRoute string, oscPort int, encoderLabel string) *encoder.Encoder {
-	e, err := encoder.New(
-		encoder.CellOpts(cell.FgColor(cell.ColorGreen)),
-		encoder.Label(encoderLabel, cell.FgColor(cell.ColorGreen)),
-		encoder.HideTextProgress(),
-		encoder.OscRoute(oscRoute, oscAddr, oscPort),
-	)
+// newReadout returns a readout of the control that sends to the OSC route.
+func newReadout(m *state.Model, oscRoute string) *readout.Readout {
+	r, err := readout.New(m, oscRoute, readout.CellOpts(cell.FgColor(cell.ColorGreen)))
 	if err != nil {
 		panic(err)
 	}
-	return e
+	return r
 }
 
-// btn creates a closure to track button press states and returns a callback function for use with the Button widget.
-func btn(oscAddr string, oscRoute string, oscPort int, encoderLabel string, display *segmentdisplay.SegmentDisplay) func() error {
-	keyState := 0
-	client := osc.NewClient(oscAddr, oscPort)
-	return func() error {
-		keyState = 1 - keyState
-		msg := osc.NewMessage(oscRoute)
-		msg.Append(int32(keyState))
-		err := client.Send(msg)
-		if err != nil {
-			log.Printf("error sending osc message: %+v", msg)
-		}
-		return display.Write([]*segmentdisplay.TextChunk{
-			segmentdisplay.NewChunk(fmt.Sprintf("%d", keyState)),
-		})
+// control is a widget with the readout of its state.
+type control struct {
+	widget  widgetapi.Widget
+	readout *readout.Readout
+}
+
+// place returns the option that places the control with its readout above
+// it, two lines for the state and the error of sending.
+func (c control) place() container.Option {
+	return container.SplitHorizontal(
+		container.Top(container.PlaceWidget(guarded{c.readout})),
+		container.Bottom(container.PlaceWidget(guarded{c.widget})),
+		container.SplitFixed(2),
+	)
+}
+
+// guarded is a widget whose panics in event handlers and while drawing are
+// returned as errors, so that they reach the error handler of the dashboard
+// instead of crashing nornsctl from a termdash goroutine with the keys held.
+type guarded struct {
+	widgetapi.Widget
+}
+
+// recoverTo reports a panic to the function, e.g. in a goroutine whose panic
+// would crash nornsctl with the keys held.
+func recoverTo(fail func(error)) {
+	if r := recover(); r != nil {
+		fail(fmt.Errorf("panic: %v", r))
 	}
 }
 
-// newGui returns a container with an even 33% vertical split for each of the encoders and buttons provided.
-func newGui(t *tcell.Terminal, e1, e2, e3 *encoder.Encoder, k1, k2, k3 *button.Button) (*container.Container, error) {
+// Draw implements widgetapi.Widget.Draw.
+func (g guarded) Draw(cvs *canvas.Canvas, meta *widgetapi.Meta) (err error) {
+	defer recoverTo(func(p error) { err = p })
+	return g.Widget.Draw(cvs, meta)
+}
+
+// Keyboard implements widgetapi.Widget.Keyboard.
+func (g guarded) Keyboard(k *terminalapi.Keyboard, meta *widgetapi.EventMeta) (err error) {
+	defer recoverTo(func(p error) { err = p })
+	return g.Widget.Keyboard(k, meta)
+}
+
+// Mouse implements widgetapi.Widget.Mouse.
+func (g guarded) Mouse(m *terminalapi.Mouse, meta *widgetapi.EventMeta) (err error) {
+	defer recoverTo(func(p error) { err = p })
+	return g.Widget.Mouse(m, meta)
+}
+
+// newGui returns a container with an even 33% vertical split for each of the encoders and keys provided.
+func newGui(t *tcell.Terminal, e1, e2, e3, k1, k2, k3 control) (*container.Container, error) {
 	return container.New(
 		t,
 		container.Border(linestyle.Light),
@@ -94,22 +164,22 @@ func newGui(t *tcell.Terminal, e1, e2, e3 *encoder.Encoder, k1, k2, k3 *button.B
 		container.SplitVertical(
 			container.Left(
 				container.SplitHorizontal(
-					container.Top(container.PlaceWidget(e1)),
-					container.Bottom(container.PlaceWidget(k1)),
+					container.Top(e1.place()),
+					container.Bottom(k1.place()),
 				),
 			),
 			container.Right(
 				container.SplitVertical(
 					container.Left(
 						container.SplitHorizontal(
-							container.Top(container.PlaceWidget(e2)),
-							container.Bottom(container.PlaceWidget(k2)),
+							container.Top(e2.place()),
+							container.Bottom(k2.place()),
 						),
 					),
 					container.Right(
 						container.SplitHorizontal(
-							container.Top(container.PlaceWidget(e3)),
-							container.Bottom(container.PlaceWidget(k3)),
+							container.Top(e3.place()),
+							container.Bottom(k3.place()),
 						),
 					),
 				),
@@ -127,6 +197,16 @@ func main() {
 	// TODO: read arg here to set osc msg route
 	oscAddrFlag := flag.String("addr", "127.0.0.1", "the ip or hostname to send OSC messages to")
 	oscPortFlag := flag.Int("port", 10111, "the port to send OSC messages to")
+	listenFlag := flag.Int("listen", 0, "the local port to receive the encoder values on at /osctl/enc/N, 0 to not listen")
+	arcFlag := flag.Bool("arc", false, "draw the encoders as monome arc rings set by /monome/ring/* messages on the listen port")
+	rateFlag := flag.Float64("rate", 30, "the maximum number of OSC messages per second to each encoder route, 0 for no limit; keys are never limited")
+	keysFlag := flag.String("keys", "123", "the keyboard keys that hold K1, K2 and K3, empty to not bind any")
+	latchFlag := flag.Bool("latch", false, "press a bound keyboard key once to hold its norns key and again, once -hold passed since its last repeat, to release it")
+	holdFlag := flag.Duration("hold", key.DefaultHoldTimeout, "how long a norns key stays held after the last repeat of its keyboard key")
+	var binds bindFlags
+	flag.Var(&binds, "bind", "play a key sequence, e.g. 'c=1d 3d+50ms 3u+100ms 1u+50ms' presses K1, K3 after 50ms and releases them when c is pressed, triggered by a keyboard key or by rightN or middleN for a click on key N, can be repeated (default right2=2d 2u+1s and right3=1d 3d+50ms 3u+100ms 1u+50ms)")
+	asciiFlag := flag.Bool("ascii", !encoder.BrailleSupported(), "draw the encoders with plain characters for terminals without braille, detected from TERM and the locale by default")
+	resetFlag := flag.Bool("reset", false, "when quitting and on P, also turn the encoders back by what they sent, like the held keys are released")
 	flag.Parse()
 
 	t, err := tcell.New()
@@ -135,41 +215,251 @@ func main() {
 	}
 	defer t.Close()
 
-	oscAddr := *oscAddrFlag
-	oscPort := *oscPortFlag
-	ctx, cancel := context.WithCancel(context.Background())
-	e1 := enc(oscAddr, "/remote/enc/1", oscPort, "E1")
-	e2 := enc(oscAddr, "/remote/enc/2", oscPort, "E2")
-	e3 := enc(oscAddr, "/remote/enc/3", oscPort, "E3")
-
-	// TODO: this is kinda messy, but handles callbacks for drawing the encoder
-	go drawEncoder(ctx, e1, 25, 1, 60*time.Millisecond)
-	go drawEncoder(ctx, e2, 25, 1, 60*time.Millisecond)
-	go drawEncoder(ctx, e3, 25, 1, 60*time.Millisecond)
+	// the model keeps what each control sent last and the errors of sending
+	// it, for the readouts
+	model := state.New()
 
-	display, err := segmentdisplay.New()
+	// all the controls send over one connection from a queue, so that a slow
+	// network never blocks the UI
+	queue, err := transport.NewQueue(
+		model.Transport(transport.NewUDP(*oscAddrFlag, *oscPortFlag)),
+		transport.Overflow(transport.Coalesce),
+		transport.Merge(mergeDeltas),
+		// the readouts show the errors instead of logging over the dashboard
+		transport.OnError(func(error) {}),
+	)
 	if err != nil {
 		panic(err)
 	}
+	// the encoders send through the limiter, the keys straight to the queue
+	// so that presses, releases and sequences keep their timing and order
+	var encTr transport.Transport = queue
+	if *rateFlag > 0 {
+		// sum up fast wheel spins so the norns doesn't lag behind
+		encTr, err = transport.NewLimiter(queue, *rateFlag, transport.Merge(mergeDeltas))
+		if err != nil {
+			panic(err)
+		}
+	}
+	// also closes the queue, after the limiter sent what is still pending
+	defer encTr.Close()
 
-	// TODO: button release requires fast double clicks
-	// this should send 1 on press and 0 on release, but the way that mouse clicks with with the termGUI it's
-	k1, _ := button.New("K1", btn(oscAddr, "/remote/key/1", oscPort, "K1", display))
-	k2, _ := button.New("K2", btn(oscAddr, "/remote/key/2", oscPort, "K2", display))
-	k3, _ := button.New("K3", btn(oscAddr, "/remote/key/3", oscPort, "K2", display))
+	// the target can push its state back to the encoders
+	var feedback *transport.Listener
+	if *listenFlag > 0 {
+		feedback, err = transport.Listen(fmt.Sprintf(":%d", *listenFlag))
+		if err != nil {
+			panic(err)
+		}
+		defer feedback.Close()
+	}
+
+	// SIGINT and SIGTERM quit like Q does, so that the held keys get released
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(sigCtx)
+	// errors of the dashboard, also panics of the widgets and of the keyboard
+	// subscriber, quit and panic once termdash returned, so that the keys are
+	// released and the terminal is restored
+	errs := make(chan error, 1)
+	failed := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+		cancel()
+	}
+	// options of encoder n, which receives from the listener
+	received := func(n int) []encoder.Option {
+		if feedback == nil {
+			return nil
+		}
+		opts := []encoder.Option{
+			encoder.Feedback(feedback, fmt.Sprintf("/osctl/enc/%d", n)),
+		}
+		if *arcFlag {
+			opts = append(opts,
+				encoder.DrawStyle(encoder.StyleRing),
+				encoder.RingOSC(feedback, "/monome", n-1),
+			)
+		}
+		return opts
+	}
+	// options of encoder n
+	encOpts := func(n int) []encoder.Option {
+		opts := received(n)
+		if *asciiFlag {
+			opts = append(opts, encoder.ASCII())
+		}
+		return opts
+	}
+	e1 := enc(encTr, "/remote/enc/1", "E1", encOpts(1)...)
+	e2 := enc(encTr, "/remote/enc/2", "E2", encOpts(2)...)
+	e3 := enc(encTr, "/remote/enc/3", "E3", encOpts(3)...)
+
+	// sequences played by keyboard keys and by mouse clicks on the keys, e.g.
+	// on "right2"
+	keySeqs := map[keyboard.Key]key.Sequence{}
+	clickSeqs := map[string]key.Sequence{}
+	// the sequences play until P stops them, it replaces seqCtx
+	var seqMu sync.Mutex
+	seqCtx, stopSeqs := context.WithCancel(ctx)
+	play := func(seq key.Sequence) {
+		seqMu.Lock()
+		playCtx := seqCtx
+		seqMu.Unlock()
+		go func() {
+			defer recoverTo(failed)
+			if err := seq.Play(playCtx); err != nil && err != context.Canceled {
+				log.Printf("error playing key sequence: %v", err)
+			}
+		}()
+	}
+	// plays the sequence of the click on key n
+	click := func(trigger string, n int) func() {
+		return func() {
+			if seq, ok := clickSeqs[fmt.Sprintf("%s%d", trigger, n)]; ok {
+				play(seq)
+			}
+		}
+	}
+
+	// options of key n, which is held with the nth of the keyboard keys
+	keyOpts := func(n int) []key.Option {
+		opts := []key.Option{
+			key.OnButton(mouse.ButtonRight, click("right", n)),
+			key.OnButton(mouse.ButtonMiddle, click("middle", n)),
+		}
+		bindings := []rune(*keysFlag)
+		if n > len(bindings) {
+			return opts
+		}
+		opts = append(opts,
+			key.Bind(keyboard.Key(bindings[n-1])),
+			key.HoldTimeout(*holdFlag),
+		)
+		if *latchFlag {
+			opts = append(opts, key.Latch())
+		}
+		return opts
+	}
+	k1 := newKey(queue, model, "/remote/key/1", "K1", keyOpts(1)...)
+	k2 := newKey(queue, model, "/remote/key/2", "K2", keyOpts(2)...)
+	k3 := newKey(queue, model, "/remote/key/3", "K3", keyOpts(3)...)
+
+	// releaseAll stops the sequences, sends 0 to the route of every held key
+	// and with -reset turns the encoders back, so that no script stays stuck
+	// in a shift mode
+	releaseAll := func() {
+		seqMu.Lock()
+		stopSeqs()
+		seqCtx, stopSeqs = context.WithCancel(ctx)
+		seqMu.Unlock()
 
-	c, err := newGui(t, e1, e2, e3, k1, k2, k3)
+		for _, k := range []*key.Key{k1, k2, k3} {
+			if err := k.Release(); err != nil {
+				log.Printf("error releasing key: %v", err)
+			}
+		}
+		if !*resetFlag {
+			return
+		}
+		for _, e := range []*encoder.Encoder{e1, e2, e3} {
+			if err := e.Reset(); err != nil {
+				log.Printf("error resetting encoder: %v", err)
+			}
+		}
+	}
+	// runs before the transport is closed, which sends what is still queued,
+	// also when main panics; the panics of the widgets, the keyboard
+	// subscriber and the sequences are recovered and quit through failed
+	defer releaseAll()
+
+	if len(binds) == 0 {
+		binds = defaultBinds
+	}
+	for _, b := range binds {
+		trigger, steps, ok := strings.Cut(b, "=")
+		if !ok {
+			panic(fmt.Sprintf("invalid -bind %q, must be TRIGGER=STEPS", b))
+		}
+		seq, err := key.ParseSequence(steps, []*key.Key{k1, k2, k3})
+		if err != nil {
+			panic(err)
+		}
+		if r := []rune(trigger); len(r) == 1 {
+			keySeqs[keyboard.Key(r[0])] = seq
+		} else {
+			clickSeqs[trigger] = seq
+		}
+	}
+
+	c, err := newGui(t,
+		control{e1, newReadout(model, "/remote/enc/1")},
+		control{e2, newReadout(model, "/remote/enc/2")},
+		control{e3, newReadout(model, "/remote/enc/3")},
+		control{k1, newReadout(model, "/remote/key/1")},
+		control{k2, newReadout(model, "/remote/key/2")},
+		control{k3, newReadout(model, "/remote/key/3")},
+	)
 	if err != nil {
 		panic(err)
 	}
 
-	quitter := func(k *terminalapi.Keyboard) {
-		if k.Key == 'q' || k.Key == 'Q' {
+	// the styles S cycles through, braille circles don't render in every font
+	styles := []encoder.Style{
+		encoder.StyleKnob,
+		encoder.StyleNeedle,
+		encoder.StyleBarHorizontal,
+		encoder.StyleBarVertical,
+		encoder.StyleNumeric,
+	}
+	if *arcFlag && feedback != nil {
+		styles = append([]encoder.Style{encoder.StyleRing}, styles...)
+	}
+	style := 0
+
+	// Q and ctrl+c quit, F (shift+f) toggles the fine adjustment on all
+	// encoders. A focused encoder toggles only its own fine adjustment on f. S
+	// (shift+s) switches the style of all encoders. P (shift+p) releases all
+	// keys like quitting does. Other keys play their -bind sequences.
+	keys := func(k *terminalapi.Keyboard) {
+		defer recoverTo(failed)
+		switch k.Key {
+		case 'q', 'Q', keyboard.KeyCtrlC:
 			cancel()
+		case 'P':
+			releaseAll()
+		case 'F':
+			fine := !e1.IsFine()
+			for _, e := range []*encoder.Encoder{e1, e2, e3} {
+				e.Fine(fine)
+			}
+		case 'S':
+			style = (style + 1) % len(styles)
+			for _, e := range []*encoder.Encoder{e1, e2, e3} {
+				if err := e.SetStyle(styles[style]); err != nil {
+					failed(err)
+					return
+				}
+			}
+		default:
+			if seq, ok := keySeqs[k.Key]; ok {
+				play(seq)
+			}
 		}
 	}
 
-	if err := termdash.Run(ctx, t, c, termdash.KeyboardSubscriber(quitter), termdash.RedrawInterval(60*time.Second)); err != nil {
+	if err := termdash.Run(ctx, t, c,
+		termdash.KeyboardSubscriber(keys),
+		termdash.RedrawInterval(100*time.Millisecond),
+		termdash.ErrorHandler(failed),
+	); err != nil {
+		panic(err)
+	}
+	select {
+	case err := <-errs:
 		panic(err)
+	default:
 	}
 }
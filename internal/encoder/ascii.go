@@ -0,0 +1,170 @@
+package encoder
+
+// ascii.go contains the fallback that draws the encoder without braille.
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/mum4k/termdash/private/canvas"
+)
+
+// BrailleSupported asserts whether the terminal is likely to display braille
+// characters, judging by the environment. The Linux console and the VT
+// terminals lack the glyphs and a locale that isn't UTF-8 can't encode them.
+// Encoders can fall back to ASCII() where it returns false.
+func BrailleSupported() bool {
+	term := os.Getenv("TERM")
+	if term == "linux" || term == "cons25" || strings.HasPrefix(term, "vt") {
+		return false
+	}
+	// The first of the variables that is set determines the locale.
+	for _, v := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if l := strings.ToLower(os.Getenv(v)); l != "" {
+			return strings.Contains(l, "utf-8") || strings.Contains(l, "utf8")
+		}
+	}
+	return true
+}
+
+const (
+	// asciiFill fills the horizontal meter up to the value.
+	asciiFill = '='
+	// asciiValue marks the value on the horizontal meter.
+	asciiValue = '|'
+	// asciiColumnFill fills the vertical meter up to the value.
+	asciiColumnFill = '#'
+	// asciiColumnValue marks the value on the vertical meter.
+	asciiColumnValue = '-'
+	// asciiGhost marks the remote value that wasn't taken over yet.
+	asciiGhost = 'o'
+)
+
+// asciiLevels are the characters of the LED levels drawn with StyleRing, from
+// off to the brightest.
+const asciiLevels = " .:-=+*#%@"
+
+// drawASCII draws the encoder in the area with plain characters, a meter
+// framed by brackets in the middle row and the text below it.
+func (d *Encoder) drawASCII(cvs *canvas.Canvas, ar image.Rectangle) error {
+	if d.opts.style == StyleBarVertical {
+		return d.drawASCIIColumn(cvs, ar)
+	}
+
+	y := ar.Min.Y + (ar.Dy()-1)/2
+	if err := d.drawASCIIMeter(cvs, image.Rect(ar.Min.X, y, ar.Max.X, y+1)); err != nil {
+		return err
+	}
+
+	if d.opts.hideTextProgress || y+1 >= ar.Max.Y {
+		return nil
+	}
+	textAr := image.Rect(ar.Min.X, y+1, ar.Max.X, y+2)
+	return d.drawTextIn(cvs, textAr, d.progressText(), d.opts.textCellOpts)
+}
+
+// drawASCIIMeter draws the meter framed by brackets on the first row of the
+// area, which must be at least three cells wide.
+func (d *Encoder) drawASCIIMeter(cvs *canvas.Canvas, ar image.Rectangle) error {
+	y := ar.Min.Y
+	ends := []struct {
+		x int
+		r rune
+	}{
+		{ar.Min.X, '['},
+		{ar.Max.X - 1, ']'},
+	}
+	for _, e := range ends {
+		if _, err := cvs.SetCell(image.Point{e.x, y}, e.r, d.ringCellOpts()...); err != nil {
+			return fmt.Errorf("failed to draw the meter: %v", err)
+		}
+	}
+
+	cells, ghost := d.asciiMeter(ar.Dx()-2, asciiFill, asciiValue)
+	for i, r := range cells {
+		cOpts := d.ringCellOpts()
+		if i == ghost {
+			cOpts = d.opts.ghostCellOpts
+		}
+		if _, err := cvs.SetCell(image.Point{ar.Min.X + 1 + i, y}, r, cOpts...); err != nil {
+			return fmt.Errorf("failed to draw the meter: %v", err)
+		}
+	}
+	return nil
+}
+
+// drawASCIIColumn draws the meter of StyleBarVertical as a column in the
+// middle of the area, filling from the bottom.
+func (d *Encoder) drawASCIIColumn(cvs *canvas.Canvas, ar image.Rectangle) error {
+	x := ar.Min.X + (ar.Dx()-1)/2
+	cells, ghost := d.asciiMeter(ar.Dy(), asciiColumnFill, asciiColumnValue)
+	for i, r := range cells {
+		cOpts := d.ringCellOpts()
+		if i == ghost {
+			cOpts = d.opts.ghostCellOpts
+		}
+		if _, err := cvs.SetCell(image.Point{x, ar.Max.Y - 1 - i}, r, cOpts...); err != nil {
+			return fmt.Errorf("failed to draw the meter: %v", err)
+		}
+	}
+	return nil
+}
+
+// asciiMeter returns the n characters of the meter and the index of the one
+// that marks the remote value that wasn't taken over, -1 if there is none.
+// The meter is filled with the fill character from the lower bound, or from
+// zero for bipolar encoders, and the value is marked with the value
+// character. Encoders with IndicatorPointer() only mark the value and
+// StyleRing shows the LED levels instead.
+// The caller must hold d.mu.
+func (d *Encoder) asciiMeter(n int, fill, value rune) ([]rune, int) {
+	cells := []rune(strings.Repeat(" ", n))
+	if d.opts.style == StyleRing {
+		for i := range cells {
+			// A cell shows the brightest of the LEDs it covers.
+			first := i * RingLEDs / n
+			last := (i + 1) * RingLEDs / n
+			if last <= first {
+				last = first + 1
+			}
+			brightest := 0
+			for _, l := range d.ring[first:last] {
+				if l > brightest {
+					brightest = l
+				}
+			}
+			cells[i] = rune(asciiLevels[(brightest*(len(asciiLevels)-1)+RingLevels-2)/(RingLevels-1)])
+		}
+		return cells, -1
+	}
+
+	// at returns the index of the character at the position in steps.
+	at := func(pos int) int {
+		return int(math.Round(float64(pos) / float64(d.total) * float64(n-1)))
+	}
+	v := at(d.current)
+	if d.opts.indicator != indicatorPointer {
+		from := 0
+		if d.opts.bipolar {
+			from = at(d.zero())
+		}
+		lo, hi := from, v
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		for i := lo; i <= hi; i++ {
+			cells[i] = fill
+		}
+	}
+	cells[v] = value
+
+	ghost := -1
+	if d.hasRemote && !d.pickedUp {
+		ghost = at(d.remotePosition())
+		cells[ghost] = asciiGhost
+	}
+	return cells, ghost
+}
@@ -0,0 +1,206 @@
+package encoder
+
+import (
+	"image"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/faketerm"
+	"github.com/mum4k/termdash/widgetapi"
+)
+
+func TestBrailleSupported(t *testing.T) {
+	tests := []struct {
+		desc string
+		env  map[string]string
+		want bool
+	}{
+		{
+			desc: "supported in a UTF-8 terminal emulator",
+			env:  map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"},
+			want: true,
+		},
+		{
+			desc: "supported without a locale",
+			env:  map[string]string{"TERM": "screen"},
+			want: true,
+		},
+		{
+			desc: "not supported on the Linux console",
+			env:  map[string]string{"TERM": "linux", "LANG": "en_US.UTF-8"},
+		},
+		{
+			desc: "not supported on VT terminals",
+			env:  map[string]string{"TERM": "vt220"},
+		},
+		{
+			desc: "not supported with a locale that isn't UTF-8",
+			env:  map[string]string{"TERM": "xterm", "LANG": "C"},
+		},
+		{
+			desc: "LC_ALL overrides LANG",
+			env:  map[string]string{"TERM": "xterm", "LC_ALL": "POSIX", "LANG": "en_US.utf8"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, v := range []string{"TERM", "LC_ALL", "LC_CTYPE", "LANG"} {
+				t.Setenv(v, tc.env[v])
+			}
+			if got := BrailleSupported(); got != tc.want {
+				t.Errorf("BrailleSupported => %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDrawASCII(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		canvas  image.Rectangle
+		percent int
+		ring    [RingLEDs]int
+		want    []string
+	}{
+		{
+			desc: "draws the meter and the text",
+			opts: []Option{
+				ASCII(),
+			},
+			canvas:  image.Rect(0, 0, 11, 3),
+			percent: 50,
+			want: []string{
+				"           ",
+				"[====|    ]",
+				"    50%    ",
+			},
+		},
+		{
+			desc: "marks the lower bound",
+			opts: []Option{
+				ASCII(),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 7, 3),
+			want: []string{
+				"       ",
+				"[|    ]",
+				"       ",
+			},
+		},
+		{
+			desc: "fills from zero when bipolar",
+			opts: []Option{
+				ASCII(),
+				Range(-1, 1, 0.25),
+				Bipolar(),
+			},
+			canvas:  image.Rect(0, 0, 11, 3),
+			percent: 25,
+			want: []string{
+				"           ",
+				"[  |==    ]",
+				"   -0.50   ",
+			},
+		},
+		{
+			desc: "only marks the value with the pointer indicator",
+			opts: []Option{
+				ASCII(),
+				IndicatorPointer(),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 7, 3),
+			percent: 100,
+			want: []string{
+				"       ",
+				"[    |]",
+				"       ",
+			},
+		},
+		{
+			desc: "draws a single row horizontal bar without the text",
+			opts: []Option{
+				ASCII(),
+				DrawStyle(StyleBarHorizontal),
+			},
+			canvas:  image.Rect(0, 0, 5, 1),
+			percent: 100,
+			want:    []string{"[==|]"},
+		},
+		{
+			desc: "draws the vertical bar as a column",
+			opts: []Option{
+				ASCII(),
+				DrawStyle(StyleBarVertical),
+			},
+			canvas:  image.Rect(0, 0, 3, 4),
+			percent: 67,
+			want: []string{
+				"   ",
+				" - ",
+				" # ",
+				" # ",
+			},
+		},
+		{
+			desc: "draws the LED levels of the ring",
+			opts: []Option{
+				ASCII(),
+				DrawStyle(StyleRing),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 6, 3),
+			ring:   levels(15, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15),
+			want: []string{
+				"      ",
+				"[@   ]",
+				"      ",
+			},
+		},
+		{
+			desc: "draws numeric as usual",
+			opts: []Option{
+				ASCII(),
+				DrawStyle(StyleNumeric),
+			},
+			canvas:  image.Rect(0, 0, 3, 1),
+			percent: 50,
+			want:    []string{"50%"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+			if err := d.SetRing(tc.ring); err != nil {
+				t.Fatalf("SetRing => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(tc.canvas)
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			if diff := pretty.Compare(tc.want, rows(ft)); diff != "" {
+				t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
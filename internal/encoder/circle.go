@@ -23,19 +23,22 @@ import (
 	"github.com/mum4k/termdash/private/canvas/braille"
 )
 
-// startEndAngles given progress indicators and the desired start angle and
-// direction, returns the starting and the ending angle of the partial circle
-// that represents this progress.
-func startEndAngles(current, total, startAngle, direction int) (start, end int) {
+// startEndAngles given progress indicators and the desired start angle, sweep
+// and direction, returns the starting and the ending angle of the partial
+// circle that represents this progress.
+// The sweep is the size of the angle in degrees that represents 100% of the
+// progress.
+func startEndAngles(current, total, startAngle, sweep, direction int) (start, end int) {
 	const fullCircle = 360
 	if total == 0 {
-		return startAngle, startAngle + 1
+		return startAngle, startAngle
 	}
 
 	mult := float64(current) / float64(total)
-	angleSize := math.Round(float64(360) * mult)
+	angleSize := math.Round(float64(sweep) * mult)
 
-	if angleSize == fullCircle {
+	if math.Abs(angleSize) == fullCircle {
+		// A full circle either way, e.g. a bipolar encoder at either end.
 		return 0, fullCircle
 	}
 	end = startAngle + int(math.Round(float64(direction)*angleSize))
@@ -57,6 +60,22 @@ func startEndAngles(current, total, startAngle, direction int) (start, end int)
 	return startAngle, end
 }
 
+// valueAngle given progress indicators and the desired start angle, sweep and
+// direction, returns the angle that points at the progress.
+// The returned angle is in range 0 <= angle < 360.
+func valueAngle(current, total, startAngle, sweep, direction int) int {
+	const fullCircle = 360
+	var angleSize int
+	if total != 0 {
+		angleSize = int(math.Round(float64(sweep) * float64(current) / float64(total)))
+	}
+	a := (startAngle + direction*angleSize) % fullCircle
+	if a < 0 {
+		a += fullCircle
+	}
+	return a
+}
+
 // midAndRadius given an area of a braille canvas, determines the mid point in
 // pixels and radius to draw the largest circle that fits.
 // The circle's mid point is always positioned on the {0,1} pixel in the chosen
@@ -116,3 +135,17 @@ func availableCells(mid image.Point, radius int) (int, image.Point) {
 	}
 	return pixels / braille.ColMult, startCell
 }
+
+// cellAngle returns the angle in degrees of the center of the cell around the
+// mid point given in pixels on a braille canvas.
+// Angles start at the X axis and grow counter-clockwise, the returned value is
+// in range 0 <= angle < 360.
+func cellAngle(mid, cell image.Point) float64 {
+	x := float64(cell.X*braille.ColMult + braille.ColMult/2 - mid.X)
+	y := float64(mid.Y - cell.Y*braille.RowMult - braille.RowMult/2)
+	angle := math.Atan2(y, x) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return angle
+}
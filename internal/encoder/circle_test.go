@@ -16,6 +16,7 @@ package encoder
 
 import (
 	"image"
+	"math"
 	"testing"
 )
 
@@ -25,6 +26,7 @@ func TestStartEndAngles(t *testing.T) {
 		current    int
 		total      int
 		startAngle int
+		sweep      int
 		direction  int
 		wantStart  int
 		wantEnd    int
@@ -34,6 +36,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    0,
 			total:      0,
 			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  90,
 			wantEnd:    90,
@@ -43,6 +46,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    0,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  90,
 			wantEnd:    90,
@@ -52,6 +56,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    90,
@@ -61,6 +66,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  1,
 			wantStart:  90,
 			wantEnd:    180,
@@ -70,6 +76,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  270,
 			wantEnd:    90,
@@ -79,6 +86,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  1,
 			wantStart:  90,
 			wantEnd:    270,
@@ -88,6 +96,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  180,
 			wantEnd:    90,
@@ -97,6 +106,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  1,
 			wantStart:  90,
 			wantEnd:    360,
@@ -106,6 +116,17 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			wantStart:  0,
+			wantEnd:    360,
+		},
+		{
+			desc:       "-100% current of a bipolar encoder, full circle",
+			current:    -100,
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -115,6 +136,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 90,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -124,6 +146,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  270,
 			wantEnd:    360,
@@ -133,6 +156,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    90,
@@ -142,6 +166,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  180,
 			wantEnd:    360,
@@ -151,6 +176,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    180,
@@ -160,6 +186,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  90,
 			wantEnd:    360,
@@ -169,6 +196,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    270,
@@ -178,6 +206,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -187,6 +216,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 0,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -196,6 +226,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  180,
 			wantEnd:    270,
@@ -205,6 +236,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  1,
 			wantStart:  270,
 			wantEnd:    360,
@@ -214,6 +246,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  90,
 			wantEnd:    270,
@@ -223,6 +256,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  1,
 			wantStart:  270,
 			wantEnd:    90,
@@ -232,6 +266,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    270,
@@ -241,6 +276,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  1,
 			wantStart:  270,
 			wantEnd:    180,
@@ -250,6 +286,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -259,6 +296,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 270,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -268,6 +306,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  90,
 			wantEnd:    180,
@@ -277,6 +316,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    25,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  1,
 			wantStart:  180,
 			wantEnd:    270,
@@ -286,6 +326,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    180,
@@ -295,6 +336,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    50,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  1,
 			wantStart:  180,
 			wantEnd:    360,
@@ -304,6 +346,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  270,
 			wantEnd:    180,
@@ -313,6 +356,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    75,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  1,
 			wantStart:  180,
 			wantEnd:    90,
@@ -322,6 +366,7 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  -1,
 			wantStart:  0,
 			wantEnd:    360,
@@ -331,15 +376,46 @@ func TestStartEndAngles(t *testing.T) {
 			current:    100,
 			total:      100,
 			startAngle: 180,
+			sweep:      360,
 			direction:  1,
 			wantStart:  0,
 			wantEnd:    360,
 		},
+		{
+			desc:       "50% current, pot-style sweep, clockwise",
+			current:    50,
+			total:      100,
+			startAngle: 240,
+			sweep:      300,
+			direction:  -1,
+			wantStart:  90,
+			wantEnd:    240,
+		},
+		{
+			desc:       "100% current, pot-style sweep, clockwise",
+			current:    100,
+			total:      100,
+			startAngle: 240,
+			sweep:      300,
+			direction:  -1,
+			wantStart:  300,
+			wantEnd:    240,
+		},
+		{
+			desc:       "50% current, half circle sweep, counter-clockwise",
+			current:    50,
+			total:      100,
+			startAngle: 0,
+			sweep:      180,
+			direction:  1,
+			wantStart:  0,
+			wantEnd:    90,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.desc, func(t *testing.T) {
-			gotStart, gotEnd := startEndAngles(tc.current, tc.total, tc.startAngle, tc.direction)
+			gotStart, gotEnd := startEndAngles(tc.current, tc.total, tc.startAngle, tc.sweep, tc.direction)
 			if gotStart != tc.wantStart || gotEnd != tc.wantEnd {
 				t.Errorf("startEndAngles => %v, %v, want %v, %v", gotStart, gotEnd, tc.wantStart, tc.wantEnd)
 			}
@@ -347,6 +423,88 @@ func TestStartEndAngles(t *testing.T) {
 	}
 }
 
+func TestValueAngle(t *testing.T) {
+	tests := []struct {
+		desc       string
+		current    int
+		total      int
+		startAngle int
+		sweep      int
+		direction  int
+		want       int
+	}{
+		{
+			desc:       "start angle without total",
+			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			want:       90,
+		},
+		{
+			desc:       "start angle without current",
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			want:       90,
+		},
+		{
+			desc:       "25% current, clockwise",
+			current:    25,
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			want:       0,
+		},
+		{
+			desc:       "50% current, clockwise, crosses zero",
+			current:    50,
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			want:       270,
+		},
+		{
+			desc:       "25% current, counter-clockwise",
+			current:    25,
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
+			direction:  1,
+			want:       180,
+		},
+		{
+			desc:       "100% current, full circle",
+			current:    100,
+			total:      100,
+			startAngle: 90,
+			sweep:      360,
+			direction:  -1,
+			want:       90,
+		},
+		{
+			desc:       "100% current, pot-style sweep",
+			current:    100,
+			total:      100,
+			startAngle: 240,
+			sweep:      300,
+			direction:  -1,
+			want:       300,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := valueAngle(tc.current, tc.total, tc.startAngle, tc.sweep, tc.direction)
+			if got != tc.want {
+				t.Errorf("valueAngle => %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestMidAndRadius(t *testing.T) {
 	tests := []struct {
 		desc      string
@@ -467,3 +625,52 @@ func TestAvailableCells(t *testing.T) {
 		})
 	}
 }
+
+func TestCellAngle(t *testing.T) {
+	tests := []struct {
+		desc string
+		mid  image.Point
+		cell image.Point
+		want float64
+	}{
+		{
+			desc: "cell right of the mid point",
+			mid:  image.Point{1, 2},
+			cell: image.Point{3, 0},
+			want: 0,
+		},
+		{
+			desc: "cell above the mid point",
+			mid:  image.Point{1, 14},
+			cell: image.Point{0, 0},
+			want: 90,
+		},
+		{
+			desc: "cell left of the mid point",
+			mid:  image.Point{9, 2},
+			cell: image.Point{0, 0},
+			want: 180,
+		},
+		{
+			desc: "cell below the mid point",
+			mid:  image.Point{1, 2},
+			cell: image.Point{0, 3},
+			want: 270,
+		},
+		{
+			desc: "cell diagonally up and right",
+			mid:  image.Point{1, 14},
+			cell: image.Point{6, 0},
+			want: 45,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := cellAngle(tc.mid, tc.cell)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("cellAngle => %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
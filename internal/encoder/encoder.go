@@ -17,24 +17,29 @@
 package encoder
 
 import (
-	"errors"
 	"fmt"
 	"image"
 	"log"
 	"math"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/hypebeast/go-osc/osc"
 	"github.com/mum4k/termdash/align"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/keyboard"
 	"github.com/mum4k/termdash/mouse"
 	"github.com/mum4k/termdash/private/alignfor"
 	"github.com/mum4k/termdash/private/area"
 	"github.com/mum4k/termdash/private/canvas"
 	"github.com/mum4k/termdash/private/canvas/braille"
 	"github.com/mum4k/termdash/private/draw"
+	"github.com/mum4k/termdash/private/numbers/trig"
 	"github.com/mum4k/termdash/private/runewidth"
 	"github.com/mum4k/termdash/terminal/terminalapi"
 	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/transport"
 )
 
 // Encoder displays the progress of an operation by filling a partial circle and
@@ -43,25 +48,94 @@ import (
 //
 // Implements widgetapi.Widget. This object is thread-safe.
 type Encoder struct {
-	// current is the current progress that will be drawn.
+	// current is the current position in steps from the lower bound that
+	// will be drawn.
 	current int
-	// the total provided by the caller
+	// total is the number of steps between the lower and the upper bound.
 	total int
-	// angle is the value that represents the angle in radians (-360, 360)
-	angle int
-
+	// absolute is true if the progress was set by Absolute(), in which case
+	// current and total are the provided numbers.
+	absolute bool
 	// dx is used to change the direction mouse events are interpreted
 	dx int
 
+	// lastStep is the time of the previous single step event, used to
+	// accelerate fast successive steps.
+	lastStep time.Time
+	// lastDir is the direction of the previous single step event.
+	lastDir int
+	// now returns the current time, replaceable in tests.
+	now func() time.Time
+
+	// detentAcc accumulates mouse movement in steps until it crosses a
+	// detent.
+	detentAcc int
+
+	// fine is true while the fine adjustment is on.
+	fine bool
+	// fineAcc accumulates the fractions of steps of the fine adjustment.
+	fineAcc float64
+
+	// lastClick and lastClickAt are the time and the position of the previous
+	// press of the left button, used to detect double clicks.
+	lastClick   time.Time
+	lastClickAt image.Point
+
+	// buttonDown is true while the left mouse button is held down, wherever
+	// it was pressed.
+	buttonDown bool
+	// dragging is true while the left mouse button is held down after being
+	// pressed on the encoder.
+	dragging bool
+	// dragFrom is the position of the previous mouse event of the drag.
+	dragFrom image.Point
+	// dragRemainder carries the partial steps of an angular drag between
+	// mouse events.
+	dragRemainder float64
+	// encoderAr is the area of the canvas the encoder was last drawn in.
+	// Used to translate mouse positions into angles around the encoder.
+	encoderAr image.Rectangle
+
 	// mu protects the Encoder.
 	mu sync.Mutex
 
 	// opts are the provided options.
 	opts *options
 
-	oscPort  int
-	oscAddr  string
-	oscRoute string
+	// changes are the changes made while handling an input event, delivered
+	// to the subscribers once d.mu is released.
+	changes []change
+	// sent is the net change in steps of all the recorded changes.
+	sent float64
+
+	// bound are the listeners and addresses the encoder is registered with,
+	// unbind removes the registrations.
+	bound  binding
+	unbind []func()
+
+	// remote is the position in steps of the value received with the
+	// feedback, valid if hasRemote is true. Only tracked with soft takeover.
+	remote    float64
+	hasRemote bool
+	// pickedUp is true once the encoder took over from the remote value.
+	pickedUp bool
+
+	// ring are the levels of the LEDs drawn with StyleRing.
+	ring [RingLEDs]int
+
+	// target sends the OSC route to the receiver set by OscTarget(), opened
+	// when first needed for the receiver and the OSC mode in targetFor.
+	target    *transport.Target
+	targetFor targetKey
+	// closed is true once Close() was called.
+	closed bool
+}
+
+// targetKey is what the target of the OSC route was opened for.
+type targetKey struct {
+	addr     string
+	port     int
+	relative bool
 }
 
 // New returns a new Encoder.
@@ -73,20 +147,21 @@ func New(opts ...Option) (*Encoder, error) {
 	if err := opt.validate(); err != nil {
 		return nil, err
 	}
-	return &Encoder{
-		oscRoute: opt.oscRoute,
-		oscPort:  opt.oscPort,
-		oscAddr:  opt.oscAddr,
-		angle:    opt.startAngle,
-		dx: -1,
-		opts:     opt,
-	}, nil
+	d := &Encoder{
+		total: opt.steps(),
+		dx:    -1,
+		now:   time.Now,
+		opts:  opt,
+	}
+	d.bind()
+	return d, nil
 }
 
 // Percent sets the current progress in percentage.
 // The provided value must be between 0 and 100.
 // Provided options override values set when New() was called.
-// TODO: make this degrees?:
+// Setting the progress only updates what is drawn, nothing is sent to the OSC
+// route.
 func (d *Encoder) Percent(p int, opts ...Option) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -101,15 +176,315 @@ func (d *Encoder) Percent(p int, opts ...Option) error {
 	if err := d.opts.validate(); err != nil {
 		return err
 	}
+	d.bind()
+
+	d.absolute = false
+	d.total = d.opts.steps()
+	d.current = int(math.Round(float64(p) / 100 * float64(d.total)))
+	d.pickedUp = d.remotePosition() == d.current
+	return nil
+}
+
+// Absolute sets the current progress in absolute numbers, e.g. 7 out of 10.
+// The current value must be between 0 and total and the total must be
+// positive. The encoder then moves between 0 and total by one on every step
+// and the displayed text shows both numbers, e.g. "7/10".
+// Provided options override values set when New() was called.
+// Setting the progress only updates what is drawn, nothing is sent to the OSC
+// route.
+func (d *Encoder) Absolute(current, total int, opts ...Option) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if current < 0 || total < 1 || current > total {
+		return fmt.Errorf("invalid progress, current(%d) and total(%d) must be 0 <= current <= total and total > 0", current, total)
+	}
+
+	for _, opt := range opts {
+		opt.set(d.opts)
+	}
+	if err := d.opts.validate(); err != nil {
+		return err
+	}
+	d.bind()
 
-	//d.current = 6
-	d.total = 100
+	d.absolute = true
+	d.total = total
+	d.current = current
+	d.pickedUp = d.remotePosition() == d.current
 	return nil
 }
 
+// Reset turns the encoder back to zero if it is bipolar and to the lower bound
+// otherwise and sends the change to the OSC route and the OnChange functions,
+// e.g. to leave the receiver in a known state on exit. The change is sent even
+// if the remote value wasn't taken over, relative to the remote value.
+// Encoders without a range or detents that send relative deltas wrap around,
+// their position doesn't tell how far the receiver moved. They send the
+// opposite of the net change sent so far instead, which turns the receiver
+// back to where it was when the encoder was created.
+// Returns the first error returned by an OnChange function.
+func (d *Encoder) Reset() error {
+	return d.input(func() {
+		d.fineAcc = 0
+		if !d.opts.clamped() {
+			d.undo()
+			return
+		}
+
+		rest := 0
+		if d.opts.bipolar {
+			rest = d.zero()
+		}
+		from := d.remotePosition()
+		d.move(rest - d.current)
+		d.pickedUp = true
+		d.remote = float64(d.current)
+		if delta := d.current - from; delta != 0 {
+			d.record(delta, 0)
+		}
+	})
+}
+
+// undo turns the encoder back by the net change sent so far. A net change in
+// fractions of a step is sent as a fraction and the drawn position moves by
+// the whole steps like the fine adjustment moved it.
+// The caller must hold d.mu.
+func (d *Encoder) undo() {
+	if d.sent == 0 {
+		return
+	}
+	delta := -int(math.Trunc(d.sent))
+	var fraction float64
+	if float64(-delta) != d.sent {
+		fraction = -d.sent
+	}
+	d.move(delta)
+	d.record(delta, fraction)
+}
+
+// binding are the listeners and addresses an encoder receives messages on.
+type binding struct {
+	feedback     *transport.Listener
+	feedbackAddr string
+	ring         *transport.Listener
+	ringPrefix   string
+	ringN        int
+}
+
+// bind registers the encoder with its listeners, replacing the previous
+// registrations when the options changed.
+// The caller must hold d.mu.
+func (d *Encoder) bind() {
+	o := d.opts
+	b := binding{
+		feedback:     o.feedback,
+		feedbackAddr: o.feedbackAddr,
+		ring:         o.ring,
+		ringPrefix:   o.ringPrefix,
+		ringN:        o.ringN,
+	}
+	if b == d.bound {
+		return
+	}
+	for _, unbind := range d.unbind {
+		unbind()
+	}
+	d.unbind = nil
+	d.bound = b
+
+	if b.feedback != nil {
+		d.unbind = append(d.unbind, b.feedback.Handle(b.feedbackAddr, d.receive))
+	}
+	if b.ring != nil {
+		for cmd, apply := range ringCommands {
+			apply := apply
+			d.unbind = append(d.unbind, b.ring.Handle(b.ringPrefix+cmd, func(msg *osc.Message) {
+				d.receiveRing(msg, apply)
+			}))
+		}
+	}
+}
+
+// receive updates the encoder from a feedback message.
+func (d *Encoder) receive(msg *osc.Message) {
+	if len(msg.Arguments) == 0 {
+		return
+	}
+	v, ok := number(msg.Arguments[0])
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var pos int
+	switch d.opts.feedbackMode {
+	case OscRelative:
+		if d.opts.takeover == TakeoverNone {
+			d.move(int(math.Round(v)))
+			return
+		}
+		pos = clampSteps(d.remotePosition()+int(math.Round(v)), d.total)
+	case OscNormalized:
+		pos = clampSteps(int(math.Round(v*float64(d.total))), d.total)
+	default:
+		pos = d.position(v)
+	}
+
+	if d.opts.takeover == TakeoverNone {
+		d.current = pos
+		return
+	}
+	d.remote = float64(pos)
+	d.hasRemote = true
+	d.pickedUp = pos == d.current
+}
+
+// remotePosition returns the position in steps of the remote value, which is
+// the position of the encoder unless a different value was received and not
+// taken over yet.
+// The caller must hold d.mu.
+func (d *Encoder) remotePosition() int {
+	if !d.hasRemote {
+		return d.current
+	}
+	return int(math.Round(d.remote))
+}
+
+// takeover applies the soft takeover after the encoder turned from the
+// position. Returns false if the change must not be sent, because the encoder
+// didn't pick up the remote value yet.
+// The caller must hold d.mu.
+func (d *Encoder) takeover(from int) bool {
+	if !d.hasRemote || d.pickedUp {
+		d.remote = float64(d.current)
+		return true
+	}
+
+	switch d.opts.takeover {
+	case TakeoverPickup:
+		if (float64(from)-d.remote)*(float64(d.current)-d.remote) > 0 {
+			return false // Didn't reach the remote value yet.
+		}
+	case TakeoverScale:
+		// The remote value moves toward the end the encoder turns to by the
+		// same proportion of its remaining distance, both reach the end
+		// together.
+		end := 0
+		if d.current > from {
+			end = d.total
+		}
+		remote := d.remote + (float64(end)-d.remote)*float64(d.current-from)/float64(end-from)
+		if (float64(from)-d.remote)*(float64(d.current)-remote) > 0 {
+			d.remote = remote
+			return true
+		}
+	}
+	d.pickedUp = true
+	d.remote = float64(d.current)
+	return true
+}
+
+// number returns the numeric OSC argument as a float64.
+func number(arg interface{}) (float64, bool) {
+	switch v := arg.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// clampSteps clamps the position in steps to 0 <= pos <= total.
+func clampSteps(pos, total int) int {
+	if pos < 0 {
+		return 0
+	}
+	if pos > total {
+		return total
+	}
+	return pos
+}
+
+// Value returns the current value of the encoder.
+// This is the value within the range for encoders with a range, the current
+// number if the progress was set by Absolute() and the position between 0 and
+// 100 otherwise.
+func (d *Encoder) Value() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.value()
+}
+
+// value returns the value at the current position.
+// The caller must hold d.mu.
+func (d *Encoder) value() float64 {
+	return d.valueAt(float64(d.current))
+}
+
+// valueAt returns the value at the position in steps.
+// The caller must hold d.mu.
+func (d *Encoder) valueAt(pos float64) float64 {
+	o := d.opts
+	switch {
+	case d.absolute:
+		return pos
+	case o.detents > 0 && !o.bounded:
+		return pos
+	case o.detents > 0:
+		return o.lowerBound + pos/float64(d.total)*(o.upperBound-o.lowerBound)
+	}
+	v := d.opts.lowerBound + pos*d.opts.step
+	return math.Min(v, d.opts.upperBound)
+}
+
+// position returns the position in steps closest to the value, the inverse of
+// value().
+// The caller must hold d.mu.
+func (d *Encoder) position(v float64) int {
+	o := d.opts
+	var pos float64
+	switch {
+	case d.absolute, o.detents > 0 && !o.bounded:
+		pos = v
+	case o.detents > 0:
+		pos = (v - o.lowerBound) / (o.upperBound - o.lowerBound) * float64(d.total)
+	default:
+		pos = (v - o.lowerBound) / o.step
+	}
+	return clampSteps(int(math.Round(pos)), d.total)
+}
+
 // progressText returns the textual representation of the current progress.
+// This is the current and the total if set by Absolute(), the label of the
+// current detent if provided, the value if the encoder has a range or detents
+// and the percentage otherwise.
 func (d *Encoder) progressText() string {
-	return fmt.Sprintf("%d%%", d.current)
+	if d.absolute {
+		return fmt.Sprintf("%d/%d", d.current, d.total)
+	}
+	if len(d.opts.detentLabels) > 0 {
+		return d.opts.detentLabels[d.current]
+	}
+	if d.opts.detents > 0 && !d.opts.bounded {
+		return strconv.Itoa(d.current)
+	}
+	if d.opts.bounded {
+		text := strconv.FormatFloat(d.value(), 'f', d.opts.precision(), 64)
+		if d.opts.bipolar && d.current > d.zero() {
+			return "+" + text
+		}
+		return text
+	}
+	return fmt.Sprintf("%d%%", int(math.Round(float64(d.current)/float64(d.total)*100)))
 }
 
 // centerRadius calculates the radius of the "center" in the encoder.
@@ -159,6 +534,151 @@ func (d *Encoder) drawLabel(cvs *canvas.Canvas, labelAr image.Rectangle) error {
 	)
 }
 
+// pointerWidth is the size in degrees of the notch that points at the
+// progress when drawing with IndicatorPointer.
+const pointerWidth = 24
+
+// drawIndicator draws the part of the encoder that indicates the progress.
+// The mid point and radius are in pixels on the braille canvas.
+func (d *Encoder) drawIndicator(bc *braille.Canvas, mid image.Point, r int) error {
+	o := d.opts
+	if o.indicator == indicatorPointer {
+		// The track covers the full sweep, the notch in it points at the
+		// progress.
+		if err := drawArc(bc, mid, r, d.total, d.total, d.startAngle(), o, d.ringCellOpts()); err != nil {
+			return err
+		}
+		a := valueAngle(d.current, d.total, d.startAngle(), o.sweep, o.direction)
+		if err := draw.BrailleCircle(bc, mid, r,
+			draw.BrailleCircleFilled(),
+			draw.BrailleCircleArcOnly((a-pointerWidth/2+360)%360, (a+pointerWidth/2)%360),
+			draw.BrailleCircleClearPixels(),
+		); err != nil {
+			return fmt.Errorf("failed to draw the pointer: %v", err)
+		}
+		return nil
+	}
+	if o.bipolar {
+		// The arc grows from zero at the top to either side.
+		return drawArc(bc, mid, r, d.current-d.zero(), d.total, bipolarZeroAngle, o, d.ringCellOpts())
+	}
+	return drawArc(bc, mid, r, d.current, d.total, d.startAngle(), o, d.ringCellOpts())
+}
+
+// bipolarZeroAngle is the angle bipolar encoders draw zero at, 12 o'clock.
+const bipolarZeroAngle = 90
+
+// startAngle returns the angle the lower bound is drawn at. Bipolar encoders
+// start where it puts zero at the top.
+// The caller must hold d.mu.
+func (d *Encoder) startAngle() int {
+	o := d.opts
+	if !o.bipolar {
+		return o.startAngle
+	}
+	zeroSize := int(math.Round(float64(o.sweep) * float64(d.zero()) / float64(d.total)))
+	a := bipolarZeroAngle - o.direction*zeroSize
+	return (a%360 + 360) % 360
+}
+
+// zero returns the position in steps closest to zero.
+// The caller must hold d.mu.
+func (d *Encoder) zero() int {
+	return d.position(0)
+}
+
+// ringCellOpts returns the cell options for the cells of the encoder circle.
+func (d *Encoder) ringCellOpts() []cell.Option {
+	if d.fine {
+		return d.opts.fineCellOpts
+	}
+	return d.opts.cellOpts
+}
+
+// drawArc draws the partial circle that represents the progress from the start
+// angle. Negative progress is drawn in the opposite direction.
+func drawArc(bc *braille.Canvas, mid image.Point, r, current, total, startAngle int, o *options, cellOpts []cell.Option) error {
+	start, end := startEndAngles(current, total, startAngle, o.sweep, o.direction)
+	if start == end {
+		return nil // No progress, nothing to draw.
+	}
+
+	circleOpts := []draw.BrailleCircleOption{
+		draw.BrailleCircleFilled(),
+		draw.BrailleCircleCellOpts(cellOpts...),
+	}
+	if start != 0 || end != 360 {
+		circleOpts = append(circleOpts, draw.BrailleCircleArcOnly(start, end))
+	}
+	if err := draw.BrailleCircle(bc, mid, r, circleOpts...); err != nil {
+		return fmt.Errorf("failed to draw the outer circle: %v", err)
+	}
+	return nil
+}
+
+// drawTicks draws a tick mark for each detent on the outer edge of the encoder.
+// The ticks toggle the pixels, so they are visible on both the filled and the
+// empty part of the circle.
+func (d *Encoder) drawTicks(bc *braille.Canvas, mid image.Point, r int) error {
+	o := d.opts
+	toggled := map[image.Point]bool{}
+	for i := 0; i < o.detents; i++ {
+		a := valueAngle(i, d.total, d.startAngle(), o.sweep, o.direction)
+		for _, tr := range []int{r, r - 1} {
+			p := trig.CirclePointAtAngle(a, mid, tr)
+			if toggled[p] || tr <= d.centerRadius(r) {
+				continue
+			}
+			toggled[p] = true
+			if err := bc.TogglePixel(p, d.ringCellOpts()...); err != nil {
+				return fmt.Errorf("failed to draw the detent tick: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// drawGhost draws a mark on the outer edge of the encoder pointing at the
+// remote value that wasn't taken over yet. Like the ticks, the mark toggles the
+// pixels.
+func (d *Encoder) drawGhost(bc *braille.Canvas, mid image.Point, r int) error {
+	o := d.opts
+	a := valueAngle(d.remotePosition(), d.total, d.startAngle(), o.sweep, o.direction)
+	for _, gr := range []int{r, r - 1, r - 2} {
+		if gr <= d.centerRadius(r) {
+			break
+		}
+		p := trig.CirclePointAtAngle(a, mid, gr)
+		if err := bc.TogglePixel(p, o.ghostCellOpts...); err != nil {
+			return fmt.Errorf("failed to draw the remote value: %v", err)
+		}
+	}
+	return nil
+}
+
+// drawKnob draws the circle or the needle that indicates the value, the detent
+// ticks and the remote value that wasn't taken over.
+func (d *Encoder) drawKnob(bc *braille.Canvas, mid image.Point, r int) error {
+	indicate := d.drawIndicator
+	if d.opts.style == StyleNeedle {
+		indicate = d.drawNeedle
+	}
+	if err := indicate(bc, mid, r); err != nil {
+		return err
+	}
+	if d.opts.detents > 0 {
+		if err := d.drawTicks(bc, mid, r); err != nil {
+			return err
+		}
+	}
+	if d.hasRemote && !d.pickedUp {
+		if err := d.drawGhost(bc, mid, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Draw draws the Encoder widget onto the canvas.
 // Implements widgetapi.Widget.Draw.
 func (d *Encoder) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
@@ -167,6 +687,10 @@ func (d *Encoder) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
 
 	var encoderAr, labelAr image.Rectangle
 	if len(d.opts.label) > 0 {
+		if cvs.Area().Dy() <= 2 {
+			// No room for the label.
+			return d.drawCompact(cvs)
+		}
 		d, l, err := encoderAndLabel(cvs.Area())
 		if err != nil {
 			return err
@@ -178,34 +702,58 @@ func (d *Encoder) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
 		encoderAr = cvs.Area()
 	}
 
-	if encoderAr.Dx() < minSize.X || encoderAr.Dy() < minSize.Y {
+	min := d.minSize()
+	if encoderAr.Dx() < min.X || encoderAr.Dy() < min.Y {
 		// Reserving area for the label might have resulted in encoderAr being
 		// too small.
-		return draw.ResizeNeeded(cvs)
+		return d.drawCompact(cvs)
+	}
+	d.encoderAr = encoderAr
+
+	switch style := d.opts.style; {
+	case style == StyleNumeric:
+		if err := d.drawNumeric(cvs, encoderAr); err != nil {
+			return err
+		}
+	case d.opts.ascii:
+		if err := d.drawASCII(cvs, encoderAr); err != nil {
+			return err
+		}
+	case style == StyleBarHorizontal || style == StyleBarVertical:
+		if err := d.drawBar(cvs, encoderAr); err != nil {
+			return err
+		}
+	default:
+		if err := d.drawCircle(cvs, encoderAr); err != nil {
+			return err
+		}
 	}
 
+	if !labelAr.Empty() {
+		if err := d.drawLabel(cvs, labelAr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawCircle draws the circular styles of the encoder in the area.
+func (d *Encoder) drawCircle(cvs *canvas.Canvas, encoderAr image.Rectangle) error {
 	bc, err := braille.New(encoderAr)
 	if err != nil {
 		return fmt.Errorf("braille.New => %v", err)
 	}
 
 	mid, r := midAndRadius(bc.Area())
-	if err := draw.BrailleCircle(bc, mid, r,
-		draw.BrailleCircleFilled(),
-		draw.BrailleCircleCellOpts(d.opts.cellOpts...),
-	); err != nil {
-		return fmt.Errorf("failed to draw the outer circle: %v", err)
-	}
-
-
-	angle := int(float64(d.current)*float64(3.6))
-	if err := draw.BrailleCircle(bc, mid, r,
-		draw.BrailleCircleFilled(),
-		draw.BrailleCircleArcOnly(angle, (angle+25)%360),
-		draw.BrailleCircleClearPixels(),
-		draw.BrailleCircleCellOpts(d.opts.cellOpts...),
-	); err != nil {
-		return fmt.Errorf("failed to draw the outer circle: %v", err)
+	switch d.opts.style {
+	case StyleRing:
+		if err := d.drawRing(bc, mid, r); err != nil {
+			return err
+		}
+	default:
+		if err := d.drawKnob(bc, mid, r); err != nil {
+			return err
+		}
 	}
 
 	centerR := d.centerRadius(r)
@@ -226,62 +774,445 @@ func (d *Encoder) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
 			return err
 		}
 	}
+	return nil
+}
 
-	if !labelAr.Empty() {
-		if err := d.drawLabel(cvs, labelAr); err != nil {
-			return err
+// Keyboard turns the encoder when its container is focused.
+// Up/Right (k/l) increment and Down/Left (j/h) decrement by one step, PgUp and
+// PgDn turn by the coarse step and Home/End turn to the minimum or maximum.
+// The 'f' key toggles the fine adjustment.
+// Implements widgetapi.Widget.Keyboard.
+func (d *Encoder) Keyboard(k *terminalapi.Keyboard, _ *widgetapi.EventMeta) error {
+	return d.input(func() { d.keyboard(k) })
+}
+
+// keyboard handles the keyboard event.
+// The caller must hold d.mu.
+func (d *Encoder) keyboard(k *terminalapi.Keyboard) {
+	switch k.Key {
+	case keyboard.KeyArrowUp, keyboard.KeyArrowRight, 'k', 'l':
+		d.adjust(d.accelerate(1), d.turn)
+	case keyboard.KeyArrowDown, keyboard.KeyArrowLeft, 'j', 'h':
+		d.adjust(d.accelerate(-1), d.turn)
+	case keyboard.KeyPgUp:
+		d.turn(d.opts.coarseSteps)
+	case keyboard.KeyPgDn:
+		d.turn(-d.opts.coarseSteps)
+	case keyboard.KeyHome:
+		d.turn(-d.current)
+	case keyboard.KeyEnd:
+		d.turn(d.total - d.current)
+	case 'f':
+		d.fine = !d.fine
+		d.fineAcc = 0
+	}
+}
+
+// Fine turns the fine adjustment on or off. While it is on, keyboard steps,
+// mouse wheel events and drags turn the encoder by a fraction of a step and
+// the encoder is drawn with the fine cell options.
+// Pressing 'f' while the encoder is focused toggles the fine adjustment.
+func (d *Encoder) Fine(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.fine = on
+	d.fineAcc = 0
+}
+
+// IsFine asserts whether the fine adjustment is on.
+func (d *Encoder) IsFine() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.fine
+}
+
+// Mouse turns the encoder on mouse wheel events and when the left button is
+// pressed on the encoder and dragged either vertically or around the circle.
+// Implements widgetapi.Widget.Mouse.
+func (d *Encoder) Mouse(m *terminalapi.Mouse, _ *widgetapi.EventMeta) error {
+	return d.input(func() { d.mouse(m) })
+}
+
+// mouse handles the mouse event.
+// The caller must hold d.mu.
+func (d *Encoder) mouse(m *terminalapi.Mouse) {
+	// Events that fall outside of the canvas are only received so that a drag
+	// released outside of the encoder ends.
+	outside := m.Position == (image.Point{-1, -1})
+
+	switch m.Button {
+	case mouse.ButtonRelease:
+		d.buttonDown = false
+		d.dragging = false
+	case mouse.ButtonWheelDown:
+		if !outside {
+			d.adjust(d.accelerate(-1*d.dx), d.turnMouse)
+		}
+	case mouse.ButtonWheelUp:
+		if !outside {
+			d.adjust(d.accelerate(d.dx), d.turnMouse)
+		}
+	case mouse.ButtonLeft:
+		// The terminal repeats the event while the button is held down and
+		// moved, only the first one can start a drag. A press elsewhere, e.g.
+		// on a key, doesn't turn the encoder when moved across it.
+		if !d.buttonDown {
+			d.buttonDown = true
+			if outside {
+				return
+			}
+			d.click(m.Position)
+			d.drag(m.Position)
+			return
+		}
+		if d.dragging && !outside {
+			d.drag(m.Position)
+		}
+	}
+}
+
+// input handles an input event under d.mu and notifies the subscribers about
+// the changes it made once the lock is released, so that subscribers can call
+// back into the encoder.
+// Returns the first error returned by a subscriber.
+func (d *Encoder) input(handle func()) error {
+	d.mu.Lock()
+	handle()
+	changes := d.changes
+	d.changes = nil
+	subs := d.subscribers()
+	d.mu.Unlock()
+
+	for _, c := range changes {
+		for _, sub := range subs {
+			if err := sub(c); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-// Keyboard input isn't supported on the Encoder widget.
-func (*Encoder) Keyboard(_ *terminalapi.Keyboard, _ *widgetapi.EventMeta) error {
-	return errors.New("the Encoder widget doesn't support keyboard events")
+// subscribers returns the functions notified about changes of the encoder, the
+// OSC route first.
+// The caller must hold d.mu.
+func (d *Encoder) subscribers() []func(change) error {
+	var subs []func(change) error
+	if o := d.opts; o.oscRoute != "" {
+		if t := d.routeTransport(); t != nil {
+			subs = append(subs, oscSender(t, o.oscRoute, o.oscMode))
+		}
+	}
+	for _, fn := range d.opts.onChange {
+		fn := fn
+		subs = append(subs, func(c change) error {
+			return fn(c.delta, c.value)
+		})
+	}
+	return subs
 }
 
-// Mouse input isn't supported on the Encoder widget.
-func (d *Encoder) Mouse(m *terminalapi.Mouse, _ *widgetapi.EventMeta) error {
+// routeTransport returns the transport the OSC route is sent with, the one
+// provided to Transport() or the target that sends to the receiver over UDP,
+// shared with the other widgets sending to it. The target is reopened when the
+// receiver or the OSC mode changed. Returns nil once the encoder was closed.
+// The caller must hold d.mu.
+func (d *Encoder) routeTransport() transport.Transport {
+	o := d.opts
+	if o.transport != nil {
+		return o.transport
+	}
+	if d.closed || !o.hasTarget {
+		return nil
+	}
+
+	key := targetKey{o.oscAddr, o.oscPort, o.oscMode == OscRelative}
+	if d.target != nil && d.targetFor == key {
+		return d.target
+	}
+	if d.target != nil {
+		if err := d.target.Close(); err != nil {
+			log.Printf("error closing osc target: %v", err)
+		}
+	}
+	merge := transport.SumDeltas
+	if !key.relative {
+		merge = transport.LatestValue
+	}
+	// The port was validated, NewTarget can't fail.
+	d.target, _ = transport.NewTarget(o.oscAddr, o.oscPort, transport.Merge(merge))
+	d.targetFor = key
+	return d.target
+}
+
+// Close closes the target set by OscTarget(), the encoder doesn't send to it
+// afterwards. Once all the widgets sending to the receiver are closed, the
+// OSC messages still queued are sent and the connection is closed. A
+// transport provided with Transport() isn't closed.
+func (d *Encoder) Close() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	client := osc.NewClient(d.oscAddr, d.oscPort)
-	msg := osc.NewMessage(d.oscRoute)
+	d.closed = true
+	if d.target == nil {
+		return nil
+	}
+	t := d.target
+	d.target = nil
+	return t.Close()
+}
+
+// doubleClickWindow is the longest time between the presses of a double click.
+const doubleClickWindow = 400 * time.Millisecond
 
-	if m.Button == mouse.ButtonWheelDown {
-		d.current = (d.current + d.dx) % d.total
-		msg.Append(int32(-1*d.dx))
+// click handles a press of the left button. Pressing twice at the same position
+// within the double click window turns bipolar encoders back to zero.
+// The caller must hold d.mu.
+func (d *Encoder) click(p image.Point) {
+	now := d.now()
+	double := now.Sub(d.lastClick) < doubleClickWindow && p == d.lastClickAt
+	d.lastClick = now
+	d.lastClickAt = p
+	if double && d.opts.bipolar {
+		d.lastClick = time.Time{} // A third press starts over.
+		d.turn(d.zero() - d.current)
 	}
-	if m.Button == mouse.ButtonWheelUp {
-		d.current = (d.current - d.dx) % d.total
-		msg.Append(int32(d.dx))
+}
+
+// accelerate multiplies the single step delta when it follows the previous
+// step in the same direction within the acceleration window.
+// Returns the delta unchanged if acceleration isn't enabled.
+// The caller must hold d.mu.
+func (d *Encoder) accelerate(delta int) int {
+	now := d.now()
+	since := now.Sub(d.lastStep)
+	sameDir := delta*d.lastDir > 0
+	d.lastStep = now
+	d.lastDir = delta
+
+	o := d.opts
+	if o.accelCurve == nil || !sameDir || since >= o.accelWindow {
+		return delta
 	}
-	if d.current < 0 {
-		d.current = 100
+	speed := 1 - float64(since)/float64(o.accelWindow)
+	mult := 1 + int(math.Round(o.accelCurve(speed)*float64(o.accelMax-1)))
+	return delta * mult
+}
+
+// drag turns the encoder proportionally to the distance between the position
+// and the previous position of the drag. The first call after the button was
+// pressed only records the position.
+// The caller must hold d.mu.
+func (d *Encoder) drag(p image.Point) {
+	if !d.dragging {
+		d.dragging = true
+		d.dragFrom = p
+		d.dragRemainder = 0
+		return
 	}
 
-	err := client.Send(msg)
-	if err != nil {
-		log.Printf("error sending osc message")
+	from := d.dragFrom
+	d.dragFrom = p
+	switch d.opts.dragMode {
+	case dragAngular:
+		bc, err := braille.New(d.encoderAr)
+		if err != nil {
+			return // Not drawn yet, nothing to rotate around.
+		}
+		mid, _ := midAndRadius(bc.Area())
+		at := d.encoderAr.Min
+		diff := cellAngle(mid, from.Sub(at)) - cellAngle(mid, p.Sub(at))
+		if diff > 180 {
+			diff -= 360
+		} else if diff < -180 {
+			diff += 360
+		}
+		// Clockwise rotation turns up and a full revolution covers the total.
+		d.dragRemainder += diff / 360 * float64(d.total)
+		steps := int(d.dragRemainder)
+		d.dragRemainder -= float64(steps)
+		d.adjust(steps, d.turn)
+
+	default:
+		d.adjust((from.Y-p.Y)*d.opts.dragSteps, d.turnMouse)
 	}
+}
 
-	return nil
+// adjust turns the encoder by delta steps of keyboard or mouse input using the
+// provided turn function. While the fine adjustment is on, the input is
+// scaled by the fine factor and accumulated until it adds up to whole steps.
+// The caller must hold d.mu.
+func (d *Encoder) adjust(delta int, turn func(int)) {
+	if !d.fine || delta == 0 {
+		turn(delta)
+		return
+	}
+
+	f := float64(delta) * d.opts.fineFactor
+	d.fineAcc += f
+	steps := int(d.fineAcc)
+	d.fineAcc -= float64(steps)
+	if !d.opts.fineFractional || d.opts.oscMode != OscRelative || d.opts.detents > 0 || d.opts.takeover != TakeoverNone {
+		turn(steps)
+		return
+	}
+
+	// Fractional deltas are sent as they are, the drawn position follows in
+	// whole steps.
+	if d.opts.clamped() && (f > 0 && d.current == d.total || f < 0 && d.current == 0) {
+		d.fineAcc = 0
+		return
+	}
+	d.record(d.move(steps), f)
+}
+
+// turnMouse turns the encoder by delta steps of mouse movement.
+// Encoders with detents only move by one detent once the mouse moved by the
+// detent steps in the same direction.
+// The caller must hold d.mu.
+func (d *Encoder) turnMouse(delta int) {
+	if d.opts.detents == 0 {
+		d.turn(delta)
+		return
+	}
+
+	if d.detentAcc*delta < 0 {
+		d.detentAcc = 0
+	}
+	d.detentAcc += delta
+	crossed := d.detentAcc / d.opts.detentSteps
+	d.detentAcc -= crossed * d.opts.detentSteps
+	d.turn(crossed)
+}
+
+// turn moves the encoder by delta steps and records the change for the
+// subscribers. Encoders with a range or detents are clamped and only record
+// the part of the delta that was applied, other encoders wrap around the
+// total.
+// The caller must hold d.mu.
+func (d *Encoder) turn(delta int) {
+	from := d.current
+	if delta = d.move(delta); delta == 0 {
+		return
+	}
+	if !d.takeover(from) {
+		return
+	}
+	d.record(delta, 0)
+}
+
+// move moves the encoder by delta steps without recording anything and
+// returns the number of steps it actually moved.
+// The caller must hold d.mu.
+func (d *Encoder) move(delta int) int {
+	if d.opts.clamped() {
+		delta = clampSteps(d.current+delta, d.total) - d.current
+	}
+	positions := d.total + 1
+	d.current = ((d.current+delta)%positions + positions) % positions
+	return delta
+}
+
+// change is a change of the encoder the subscribers are notified about.
+type change struct {
+	// delta is the change in whole steps.
+	delta int
+	// fraction is the change in fractions of a step of the fine adjustment,
+	// zero unless fractional deltas are sent.
+	fraction float64
+	// value is the value after the change.
+	value float64
+	// normalized is the position after the change in range 0 <= n <= 1.
+	normalized float64
+}
+
+// record records a change of the encoder by delta steps, or by the fraction
+// of a step when it isn't zero, to be delivered to the subscribers.
+// The value is the remote one while it is being taken over.
+// The caller must hold d.mu.
+func (d *Encoder) record(delta int, fraction float64) {
+	pos := float64(d.current)
+	if d.hasRemote {
+		pos = d.remote
+	}
+	if fraction != 0 {
+		d.sent += fraction
+	} else {
+		d.sent += float64(delta)
+	}
+	d.changes = append(d.changes, change{
+		delta:      delta,
+		fraction:   fraction,
+		value:      d.valueAt(pos),
+		normalized: pos / float64(d.total),
+	})
+}
+
+// oscSender returns a subscriber that sends the changes to the OSC route with
+// the transport according to the OSC mode.
+// Errors sending are logged and not returned, a receiver that went away
+// shouldn't stop the dashboard.
+func oscSender(t transport.Transport, route string, mode OscMode) func(change) error {
+	return func(c change) error {
+		msg := osc.NewMessage(route)
+		switch {
+		case c.fraction != 0:
+			msg.Append(float32(c.fraction))
+		case mode == OscAbsoluteInt:
+			msg.Append(int32(math.Round(c.value)))
+		case mode == OscAbsoluteFloat:
+			msg.Append(float32(c.value))
+		case mode == OscNormalized:
+			msg.Append(float32(c.normalized))
+		default:
+			msg.Append(int32(c.delta))
+		}
+
+		if err := t.Send(msg); err != nil {
+			log.Printf("error sending osc message: %v", err)
+		}
+		return nil
+	}
 }
 
 // minSize is the smallest area we can draw encoder on.
 var minSize = image.Point{3, 3}
 
+// minSize returns the smallest area the encoder can be drawn on in its style,
+// smaller areas get the compact rendering.
+// The caller must hold d.mu.
+func (d *Encoder) minSize() image.Point {
+	switch d.opts.style {
+	case StyleBarHorizontal:
+		return image.Point{3, 1}
+	case StyleBarVertical:
+		return image.Point{1, 3}
+	case StyleNumeric:
+		return image.Point{1, 1}
+	}
+	// The smallest circle that "looks" like a circle on the canvas.
+	return minSize
+}
+
 // Options implements widgetapi.Widget.Options.
+// The encoder doesn't ask for a minimum size, areas too small for the style
+// get the compact rendering instead of a request to resize.
 func (d *Encoder) Options() widgetapi.Options {
-	return widgetapi.Options{
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var ratio image.Point
+	if d.opts.style.circular() {
 		// We are drawing a circle, ensure equal ratio of rows and columns.
 		// This is adjusted for the inequality of the braille canvas.
-		Ratio: image.Point{braille.RowMult, braille.ColMult},
-
-		// The smallest circle that "looks" like a circle on the canvas.
-		MinimumSize:  minSize,
-		WantKeyboard: widgetapi.KeyScopeNone,
-		WantMouse:    widgetapi.MouseScopeWidget,
+		ratio = image.Point{braille.RowMult, braille.ColMult}
+	}
+	return widgetapi.Options{
+		Ratio:        ratio,
+		WantKeyboard: widgetapi.KeyScopeFocused,
+		WantMouse:    widgetapi.MouseScopeGlobal,
 	}
 }
 
@@ -15,12 +15,19 @@
 package encoder
 
 import (
+	"errors"
 	"image"
+	"math"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/hypebeast/go-osc/osc"
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/mum4k/termdash/align"
 	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/keyboard"
+	"github.com/mum4k/termdash/mouse"
 	"github.com/mum4k/termdash/private/canvas"
 	"github.com/mum4k/termdash/private/canvas/braille/testbraille"
 	"github.com/mum4k/termdash/private/canvas/testcanvas"
@@ -29,13 +36,14 @@ import (
 	"github.com/mum4k/termdash/private/faketerm"
 	"github.com/mum4k/termdash/terminal/terminalapi"
 	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/transport"
 )
 
-func TestEncoder.g(t *testing.T) {
+func TestEncoder(t *testing.T) {
 	tests := []struct {
 		desc          string
 		opts          []Option
-		update        func(*Encoder.g) error // update gets called before drawing of the widget.
+		update        func(*Encoder) error // update gets called before drawing of the widget.
 		canvas        image.Rectangle
 		meta          *widgetapi.Meta
 		want          func(size image.Point) *faketerm.Terminal
@@ -46,7 +54,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc: "New fails on negative encoder hole percent",
 			opts: []Option{
-				HolePercent(-1),
+				CenterPercent(-1),
 			},
 			canvas:     image.Rect(0, 0, 3, 3),
 			wantNewErr: true,
@@ -54,7 +62,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc: "New fails on too large encoder hole percent",
 			opts: []Option{
-				HolePercent(101),
+				CenterPercent(101),
 			},
 			canvas:     image.Rect(0, 0, 3, 3),
 			wantNewErr: true,
@@ -78,15 +86,139 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Percent fails on too small start angle",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100, StartAngle(-1))
 			},
 			wantUpdateErr: true,
 		},
+		{
+			desc: "New fails on zero sweep",
+			opts: []Option{
+				Sweep(0),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on too large sweep",
+			opts: []Option{
+				Sweep(361),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on a single detent",
+			opts: []Option{
+				Detents(1),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails when detent labels don't match detents",
+			opts: []Option{
+				Detents(3, "a", "b"),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on zero detent steps",
+			opts: []Option{
+				Detents(3),
+				DetentSteps(0),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on zero fine factor",
+			opts: []Option{
+				FineAdjust(0),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on too large fine factor",
+			opts: []Option{
+				FineAdjust(1.5),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on an OSC route without a target or a transport",
+			opts: []Option{
+				OscRoute("/remote/enc/1"),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on an invalid OSC target port",
+			opts: []Option{
+				OscRoute("/remote/enc/1"),
+				OscTarget("127.0.0.1", 70000),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on soft takeover with relative OSC mode",
+			opts: []Option{
+				OscRoute("/remote/enc/1"),
+				Takeover(TakeoverPickup),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on unknown style",
+			opts: []Option{
+				DrawStyle(StyleNumeric + 1),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on negative ring number",
+			opts: []Option{
+				RingOSC(nil, "/monome", -1),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on bipolar without a range",
+			opts: []Option{
+				Bipolar(),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on bipolar with a range that excludes zero",
+			opts: []Option{
+				Range(1, 10, 1),
+				Bipolar(),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on nil OnChange function",
+			opts: []Option{
+				OnChange(nil),
+			},
+			canvas:     image.Rect(0, 0, 3, 3),
+			wantNewErr: true,
+		},
 		{
 			desc:   "Percent fails on negative percent",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(-1)
 			},
 			wantUpdateErr: true,
@@ -94,7 +226,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Percent fails on value too large",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(101)
 			},
 			wantUpdateErr: true,
@@ -102,7 +234,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Absolute fails on too small start angle",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(100, 100, StartAngle(-1))
 			},
 			wantUpdateErr: true,
@@ -110,7 +242,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Absolute fails on done to small",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(-1, 100)
 			},
 			wantUpdateErr: true,
@@ -118,7 +250,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Absolute fails on total to small",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(0, 0)
 			},
 			wantUpdateErr: true,
@@ -126,29 +258,29 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Absolute fails on done greater than total",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(2, 1)
 			},
 			wantUpdateErr: true,
 		},
 
 		{
-			desc:   "draws empty for no data points",
-			canvas: image.Rect(0, 0, 1, 1),
+			desc:   "draws empty for zero progress",
+			canvas: image.Rect(0, 0, 3, 3),
 			want: func(size image.Point) *faketerm.Terminal {
 				return faketerm.MustNew(size)
 			},
 		},
 		{
-			desc: "fails when canvas too small to draw a circle",
-			update: func(d *Encoder.g) error {
+			desc: "draws compact when canvas too small to draw a circle",
+			update: func(d *Encoder) error {
 				return d.Percent(100)
 			},
 			canvas: image.Rect(0, 0, 1, 1),
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				cvs := testcanvas.MustNew(ft.Area())
-				testdraw.MustResizeNeeded(cvs)
+				testdraw.MustText(cvs, "…", image.Point{0, 0})
 				testcanvas.MustApply(cvs, ft)
 				return ft
 			},
@@ -156,7 +288,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "smallest valid encoder, 100% progress",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
@@ -170,18 +302,19 @@ func TestEncoder.g(t *testing.T) {
 			},
 		},
 		{
-			desc: "adding label to the smallest canvas makes it too small",
+			desc: "adding label to the smallest canvas draws compact",
 			opts: []Option{
 				Label("hi"),
 			},
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				cvs := testcanvas.MustNew(ft.Area())
-				testdraw.MustResizeNeeded(cvs)
+				// The value takes precedence over the label.
+				testdraw.MustText(cvs, "10…", image.Point{0, 1})
 				testcanvas.MustApply(cvs, ft)
 				return ft
 			},
@@ -195,7 +328,7 @@ func TestEncoder.g(t *testing.T) {
 				),
 			},
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
@@ -217,7 +350,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Percent sets encoder options",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100,
 					CellOpts(
 						cell.FgColor(cell.ColorRed),
@@ -244,7 +377,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "Absolute sets encoder options",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(100, 100,
 					CellOpts(
 						cell.FgColor(cell.ColorRed),
@@ -271,7 +404,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "smallest valid encoder, 100 absolute",
 			canvas: image.Rect(0, 0, 3, 3),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Absolute(100, 100)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
@@ -287,7 +420,7 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "smallest valid encoder with a hole",
 			canvas: image.Rect(0, 0, 6, 6),
-			update: func(d *Encoder.g) error {
+			update: func(d *Encoder) error {
 				return d.Percent(100)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
@@ -307,8 +440,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "draws a larger hole",
 			canvas: image.Rect(0, 0, 6, 6),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(50))
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(50))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -330,8 +463,8 @@ func TestEncoder.g(t *testing.T) {
 				Label("hi"),
 			},
 			canvas: image.Rect(0, 0, 6, 6),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(50))
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(50))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -354,8 +487,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "hole as large as encoder",
 			canvas: image.Rect(0, 0, 6, 6),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(100), HideTextProgress())
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(100), HideTextProgress())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -374,8 +507,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 100% progress",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -398,8 +531,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "sets text cell options",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80), TextCellOpts(
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80), TextCellOpts(
 					cell.FgColor(cell.ColorGreen),
 					cell.BgColor(cell.ColorYellow),
 				))
@@ -431,8 +564,8 @@ func TestEncoder.g(t *testing.T) {
 				HideTextProgress(),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80), ShowTextProgress())
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80), ShowTextProgress())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -455,8 +588,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "hides text when requested",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80), HideTextProgress())
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80), HideTextProgress())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -477,8 +610,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "hides text when hole is too small",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(50))
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(50))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -499,8 +632,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 1% progress",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(1, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(1, CenterPercent(80))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -526,8 +659,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 25% progress, clockwise",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(25, HolePercent(80), Clockwise())
+			update: func(d *Encoder) error {
+				return d.Percent(25, CenterPercent(80), Clockwise())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -553,8 +686,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 25% progress, counter-clockwise",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(25, HolePercent(80), CounterClockwise())
+			update: func(d *Encoder) error {
+				return d.Percent(25, CenterPercent(80), CounterClockwise())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -580,8 +713,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 10/10 absolute progress",
 			canvas: image.Rect(0, 0, 8, 8),
-			update: func(d *Encoder.g) error {
-				return d.Absolute(10, 10, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Absolute(10, 10, CenterPercent(80))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -604,8 +737,8 @@ func TestEncoder.g(t *testing.T) {
 		{
 			desc:   "displays 1/10 absolute progress",
 			canvas: image.Rect(0, 0, 8, 8),
-			update: func(d *Encoder.g) error {
-				return d.Absolute(1, 10, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Absolute(1, 10, CenterPercent(80))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
@@ -629,202 +762,555 @@ func TestEncoder.g(t *testing.T) {
 			},
 		},
 		{
-			desc: "displays text label under the encoder",
-			opts: []Option{
-				Label("hi"),
-			},
+			desc:   "displays 50% progress, pot-style sweep",
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(50, CenterPercent(80), StartAngle(240), Sweep(300), Clockwise())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(90, 240),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 5,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
-
-				testdraw.MustText(c, "hi", image.Point{2, 6})
+				testdraw.MustText(c, "50%", image.Point{2, 3})
 
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
 		{
-			desc: "aligns text label center with option",
+			desc: "pointer indicates 25% progress",
 			opts: []Option{
-				Label("hi"),
-				LabelAlign(align.HorizontalCenter),
+				IndicatorPointer(),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(25, CenterPercent(80))
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(348, 12),
+					draw.BrailleCircleClearPixels(),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 5,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
-
-				testdraw.MustText(c, "hi", image.Point{2, 6})
+				testdraw.MustText(c, "25%", image.Point{2, 3})
 
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
 		{
-			desc: "aligns text label left",
+			desc: "pointer draws the track of a pot-style sweep",
 			opts: []Option{
-				Label("hi"),
-				LabelAlign(align.HorizontalLeft),
+				IndicatorPointer(),
+				StartAngle(240),
+				Sweep(300),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(0, CenterPercent(80), HideTextProgress())
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(300, 240),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(228, 252),
+					draw.BrailleCircleClearPixels(),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 5,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "draws detent ticks and label",
+			opts: []Option{
+				Detents(3, "maj", "min", "dor"),
+				Sweep(180),
+				CenterPercent(80),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustText(c, "hi", image.Point{0, 6})
+				testbraille.MustSetPixel(bc, image.Point{6, 7})
+				testbraille.MustSetPixel(bc, image.Point{12, 13})
+				testbraille.MustSetPixel(bc, image.Point{6, 19})
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "maj", image.Point{2, 3})
 
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
 		{
-			desc: "aligns text label right",
+			desc: "detent ticks clear the filled part",
 			opts: []Option{
-				Label("hi"),
-				LabelAlign(align.HorizontalRight),
+				Detents(3),
+				Sweep(180),
+				CenterPercent(80),
+				HideTextProgress(),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Absolute(2, 2)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(270, 90),
+				)
+				testbraille.MustClearPixel(bc, image.Point{6, 7})
+				testbraille.MustClearPixel(bc, image.Point{12, 13})
+				testbraille.MustClearPixel(bc, image.Point{6, 19})
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 5,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
-
-				testdraw.MustText(c, "hi", image.Point{5, 6})
-
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
 		{
-			desc: "sets cell options on text label",
+			desc: "draws the remote value that wasn't taken over",
 			opts: []Option{
-				Label(
-					"hi",
-					cell.FgColor(cell.ColorRed),
-					cell.BgColor(cell.ColorBlue),
-				),
+				Feedback(nil, "/fb"),
+				Takeover(TakeoverPickup),
+				HideTextProgress(),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				d.receive(osc.NewMessage("/fb", float32(50)))
+				return nil
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testbraille.MustSetPixel(bc, image.Point{6, 19}, cell.FgColor(cell.ColorRed))
+				testbraille.MustSetPixel(bc, image.Point{6, 18}, cell.FgColor(cell.ColorRed))
+				testbraille.MustSetPixel(bc, image.Point{6, 17}, cell.FgColor(cell.ColorRed))
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "doesn't draw the remote value once taken over",
+			opts: []Option{
+				Feedback(nil, "/fb"),
+				Takeover(TakeoverPickup),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				d.receive(osc.NewMessage("/fb", float32(50)))
+				return d.Percent(50)
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustText(
-					c,
-					"hi",
-					image.Point{2, 6},
-					draw.TextCellOpts(
-						cell.FgColor(cell.ColorRed),
-						cell.BgColor(cell.ColorBlue),
-					),
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(270, 90),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
 				)
+				testbraille.MustCopyTo(bc, c)
 
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
 		{
-			desc: "text label too long, gets trimmed",
+			desc: "needle points at the value from the middle",
 			opts: []Option{
-				Label(
-					"hello world",
-				),
+				DrawStyle(StyleNeedle),
+				HideTextProgress(),
 			},
 			canvas: image.Rect(0, 0, 7, 7),
-			update: func(d *Encoder.g) error {
-				return d.Percent(100, HolePercent(80))
+			update: func(d *Encoder) error {
+				return d.Percent(25)
 			},
 			want: func(size image.Point) *faketerm.Terminal {
 				ft := faketerm.MustNew(size)
 				c := testcanvas.MustNew(ft.Area())
 				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
-				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6)
+				testdraw.MustBrailleLine(bc, image.Point{6, 13}, image.Point{12, 13})
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
 					draw.BrailleCircleFilled(),
 					draw.BrailleCircleClearPixels(),
 				)
 				testbraille.MustCopyTo(bc, c)
 
-				testdraw.MustText(c, "100%", image.Point{2, 2})
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "bipolar draws negative values counter-clockwise from the top",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(25)
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
 
-				testdraw.MustText(c, "hello …", image.Point{0, 6})
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(90, 180),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
 
 				testcanvas.MustApply(c, ft)
 				return ft
 			},
 		},
-	}
+		{
+			desc: "bipolar draws positive values clockwise from the top",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100)
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
 
-	for _, tc := range tests {
-		t.Run(tc.desc, func(t *testing.T) {
-			d, err := New(tc.opts...)
-			if (err != nil) != tc.wantNewErr {
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 6,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleArcOnly(270, 90),
+				)
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "bipolar draws nothing at zero",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+				HideTextProgress(),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(50)
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 13}, 2,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "draws with fine cell options while the fine adjustment is on",
+			opts: []Option{
+				FineCellOpts(cell.FgColor(cell.ColorRed)),
+			},
+			canvas: image.Rect(0, 0, 3, 3),
+			update: func(d *Encoder) error {
+				d.Fine(true)
+				return d.Percent(100)
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				bc := testbraille.MustNew(ft.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{2, 5}, 2,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleCellOpts(cell.FgColor(cell.ColorRed)),
+				)
+
+				testbraille.MustApply(bc, ft)
+				return ft
+			},
+		},
+		{
+			desc: "displays text label under the encoder",
+			opts: []Option{
+				Label("hi"),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(c, "hi", image.Point{2, 6})
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "aligns text label center with option",
+			opts: []Option{
+				Label("hi"),
+				LabelAlign(align.HorizontalCenter),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(c, "hi", image.Point{2, 6})
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "aligns text label left",
+			opts: []Option{
+				Label("hi"),
+				LabelAlign(align.HorizontalLeft),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(c, "hi", image.Point{0, 6})
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "aligns text label right",
+			opts: []Option{
+				Label("hi"),
+				LabelAlign(align.HorizontalRight),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(c, "hi", image.Point{5, 6})
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "sets cell options on text label",
+			opts: []Option{
+				Label(
+					"hi",
+					cell.FgColor(cell.ColorRed),
+					cell.BgColor(cell.ColorBlue),
+				),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(
+					c,
+					"hi",
+					image.Point{2, 6},
+					draw.TextCellOpts(
+						cell.FgColor(cell.ColorRed),
+						cell.BgColor(cell.ColorBlue),
+					),
+				)
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+		{
+			desc: "text label too long, gets trimmed",
+			opts: []Option{
+				Label(
+					"hello world",
+				),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			update: func(d *Encoder) error {
+				return d.Percent(100, CenterPercent(80))
+			},
+			want: func(size image.Point) *faketerm.Terminal {
+				ft := faketerm.MustNew(size)
+				c := testcanvas.MustNew(ft.Area())
+				bc := testbraille.MustNew(c.Area())
+
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 6, draw.BrailleCircleFilled())
+				testdraw.MustBrailleCircle(bc, image.Point{6, 9}, 5,
+					draw.BrailleCircleFilled(),
+					draw.BrailleCircleClearPixels(),
+				)
+				testbraille.MustCopyTo(bc, c)
+
+				testdraw.MustText(c, "100%", image.Point{2, 2})
+
+				testdraw.MustText(c, "hello …", image.Point{0, 6})
+
+				testcanvas.MustApply(c, ft)
+				return ft
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(tc.opts...)
+			if (err != nil) != tc.wantNewErr {
 				t.Errorf("New => unexpected error: %v, wantNewErr: %v", err, tc.wantNewErr)
 			}
 			if err != nil {
@@ -878,28 +1364,1725 @@ func TestEncoder.g(t *testing.T) {
 	}
 }
 
-func TestKeyboard(t *testing.T) {
-	d, err := New()
-	if err != nil {
-		t.Fatalf("New => unexpected error: %v", err)
+// arguments returns the arguments of the messages sent to the route.
+func arguments(t *testing.T, msgs []*osc.Message, route string) []interface{} {
+	t.Helper()
+	var got []interface{}
+	for _, m := range msgs {
+		if m.Address != route {
+			t.Errorf("message sent to %q, want %q", m.Address, route)
+		}
+		got = append(got, m.Arguments...)
 	}
-	if err := d.Keyboard(&terminalapi.Keyboard{}, &widgetapi.EventMeta{}); err == nil {
-		t.Errorf("Keyboard => got nil err, wanted one")
+	return got
+}
+
+// deltas returns the int32 arguments of the messages sent to the route.
+func deltas(t *testing.T, msgs []*osc.Message, route string) []int32 {
+	t.Helper()
+	var got []int32
+	for _, a := range arguments(t, msgs, route) {
+		got = append(got, a.(int32))
 	}
+	return got
 }
 
-func TestMouse(t *testing.T) {
-	d, err := New()
-	if err != nil {
-		t.Fatalf("New => unexpected error: %v", err)
+func TestKeyboard(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		percent int
+		keys    []keyboard.Key
+		want    []int32
+	}{
+		{
+			desc: "arrows and hjkl turn by one step",
+			keys: []keyboard.Key{
+				keyboard.KeyArrowUp,
+				keyboard.KeyArrowRight,
+				'k',
+				'l',
+				keyboard.KeyArrowDown,
+				keyboard.KeyArrowLeft,
+				'j',
+				'h',
+			},
+			want: []int32{1, 1, 1, 1, -1, -1, -1, -1},
+		},
+		{
+			desc: "PgUp and PgDn turn by the default coarse step",
+			keys: []keyboard.Key{keyboard.KeyPgUp, keyboard.KeyPgDn},
+			want: []int32{10, -10},
+		},
+		{
+			desc: "PgUp and PgDn turn by the configured coarse step",
+			opts: []Option{
+				CoarseSteps(5),
+			},
+			keys: []keyboard.Key{keyboard.KeyPgUp, keyboard.KeyPgDn},
+			want: []int32{5, -5},
+		},
+		{
+			desc: "Home and End turn to the minimum and maximum",
+			keys: []keyboard.Key{keyboard.KeyPgUp, keyboard.KeyHome, keyboard.KeyEnd},
+			want: []int32{10, -10, 100},
+		},
+		{
+			desc: "Home at the minimum sends nothing",
+			keys: []keyboard.Key{keyboard.KeyHome},
+		},
+		{
+			desc: "ignores other keys",
+			keys: []keyboard.Key{'q', keyboard.KeyEnter},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, k := range tc.keys {
+				if err := d.Keyboard(&terminalapi.Keyboard{Key: k}, &widgetapi.EventMeta{Focused: true}); err != nil {
+					t.Fatalf("Keyboard(%v) => unexpected error: %v", k, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Keyboard => unexpected deltas (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMouse(t *testing.T) {
+	tests := []struct {
+		desc   string
+		opts   []Option
+		canvas image.Rectangle // canvas the encoder is drawn on before the events, if any.
+		events []*terminalapi.Mouse
+		want   []int32
+	}{
+		{
+			desc: "wheel turns by one step",
+			events: []*terminalapi.Mouse{
+				{Button: mouse.ButtonWheelDown},
+				{Button: mouse.ButtonWheelUp},
+				{Button: mouse.ButtonWheelUp},
+			},
+			want: []int32{1, -1, -1},
+		},
+		{
+			desc: "ignores wheel outside of the encoder",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{-1, -1}, Button: mouse.ButtonWheelDown},
+			},
+		},
+		{
+			desc: "ignores clicks",
+			events: []*terminalapi.Mouse{
+				{Button: mouse.ButtonLeft},
+				{Button: mouse.ButtonRelease},
+				{Button: mouse.ButtonRight},
+			},
+		},
+		{
+			desc: "vertical drag turns by the number of cells",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 1}, Button: mouse.ButtonLeft},
+				{Position: image.Point{5, 4}, Button: mouse.ButtonLeft},
+				{Position: image.Point{5, 4}, Button: mouse.ButtonRelease},
+			},
+			want: []int32{2, -3},
+		},
+		{
+			desc: "vertical drag with configured steps",
+			opts: []Option{
+				DragSteps(5),
+			},
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 1}, Button: mouse.ButtonLeft},
+			},
+			want: []int32{10},
+		},
+		{
+			desc: "new drag starts from the new press",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 2}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 2}, Button: mouse.ButtonRelease},
+				{Position: image.Point{3, 6}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 5}, Button: mouse.ButtonLeft},
+			},
+			want: []int32{1, 1},
+		},
+		{
+			desc: "drag continues after leaving the encoder",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{-1, -1}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 2}, Button: mouse.ButtonLeft},
+			},
+			want: []int32{1},
+		},
+		{
+			desc: "release outside of the encoder ends the drag",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{-1, -1}, Button: mouse.ButtonRelease},
+				{Position: image.Point{3, 0}, Button: mouse.ButtonLeft},
+			},
+		},
+		{
+			desc: "press outside of the encoder doesn't drag across it",
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{-1, -1}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 1}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 1}, Button: mouse.ButtonRelease},
+				{Position: image.Point{3, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 2}, Button: mouse.ButtonLeft},
+			},
+			want: []int32{1},
+		},
+		{
+			desc: "angular drag turns proportionally to the angle",
+			opts: []Option{
+				DragAngular(),
+			},
+			canvas: image.Rect(0, 0, 7, 7),
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 0}, Button: mouse.ButtonLeft},
+				{Position: image.Point{6, 3}, Button: mouse.ButtonLeft},
+				{Position: image.Point{3, 6}, Button: mouse.ButtonLeft},
+				{Position: image.Point{6, 3}, Button: mouse.ButtonLeft},
+			},
+			want: []int32{25, 22, -21},
+		},
+		{
+			desc: "angular drag before the encoder was drawn",
+			opts: []Option{
+				DragAngular(),
+			},
+			events: []*terminalapi.Mouse{
+				{Position: image.Point{3, 0}, Button: mouse.ButtonLeft},
+				{Position: image.Point{6, 3}, Button: mouse.ButtonLeft},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			if !tc.canvas.Empty() {
+				c, err := canvas.New(tc.canvas)
+				if err != nil {
+					t.Fatalf("canvas.New => unexpected error: %v", err)
+				}
+				if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+					t.Fatalf("Draw => unexpected error: %v", err)
+				}
+			}
+
+			for _, m := range tc.events {
+				if err := d.Mouse(m, &widgetapi.EventMeta{}); err != nil {
+					t.Fatalf("Mouse(%v) => unexpected error: %v", m, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Mouse => unexpected deltas (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAcceleration(t *testing.T) {
+	type step struct {
+		at     time.Duration // time of the event since the start of the test.
+		button mouse.Button
+	}
+	tests := []struct {
+		desc  string
+		opts  []Option
+		steps []step
+		want  []int32
+	}{
+		{
+			desc: "disabled by default",
+			steps: []step{
+				{0, mouse.ButtonWheelDown},
+				{0, mouse.ButtonWheelDown},
+			},
+			want: []int32{1, 1},
+		},
+		{
+			desc: "linear curve multiplies fast steps",
+			opts: []Option{
+				Acceleration(LinearAccel, 100*time.Millisecond, 5),
+			},
+			steps: []step{
+				{0, mouse.ButtonWheelDown},
+				{0, mouse.ButtonWheelDown},
+				{50 * time.Millisecond, mouse.ButtonWheelDown},
+				{75 * time.Millisecond, mouse.ButtonWheelDown},
+			},
+			want: []int32{1, 5, 3, 4},
+		},
+		{
+			desc: "slow steps are not multiplied",
+			opts: []Option{
+				Acceleration(LinearAccel, 100*time.Millisecond, 5),
+			},
+			steps: []step{
+				{0, mouse.ButtonWheelDown},
+				{100 * time.Millisecond, mouse.ButtonWheelDown},
+				{300 * time.Millisecond, mouse.ButtonWheelDown},
+			},
+			want: []int32{1, 1, 1},
+		},
+		{
+			desc: "change of direction is not multiplied",
+			opts: []Option{
+				Acceleration(LinearAccel, 100*time.Millisecond, 5),
+			},
+			steps: []step{
+				{0, mouse.ButtonWheelDown},
+				{0, mouse.ButtonWheelUp},
+				{0, mouse.ButtonWheelUp},
+			},
+			want: []int32{1, -1, -5},
+		},
+		{
+			desc: "quadratic curve",
+			opts: []Option{
+				Acceleration(QuadraticAccel, 100*time.Millisecond, 5),
+			},
+			steps: []step{
+				{0, mouse.ButtonWheelDown},
+				{50 * time.Millisecond, mouse.ButtonWheelDown},
+				{60 * time.Millisecond, mouse.ButtonWheelDown},
+			},
+			want: []int32{1, 2, 4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			start := time.Now()
+			for _, s := range tc.steps {
+				d.now = func() time.Time { return start.Add(s.at) }
+				if err := d.Mouse(&terminalapi.Mouse{Button: s.button}, &widgetapi.EventMeta{}); err != nil {
+					t.Fatalf("Mouse(%v) => unexpected error: %v", s.button, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Mouse => unexpected deltas (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		desc       string
+		opts       []Option
+		keys       []keyboard.Key
+		wantNewErr bool
+		want       []int32
+		wantText   string
+	}{
+		{
+			desc: "New fails when min isn't less than max",
+			opts: []Option{
+				Range(10, 10, 1),
+			},
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on zero step",
+			opts: []Option{
+				Range(0, 10, 0),
+			},
+			wantNewErr: true,
+		},
+		{
+			desc: "New fails on step larger than the range",
+			opts: []Option{
+				Range(0, 10, 11),
+			},
+			wantNewErr: true,
+		},
+		{
+			desc:     "encoder without a range wraps around",
+			keys:     []keyboard.Key{keyboard.KeyArrowDown, keyboard.KeyArrowDown},
+			want:     []int32{-1, -1},
+			wantText: "99%",
+		},
+		{
+			desc: "starts at the minimum",
+			opts: []Option{
+				Range(20, 20000, 1),
+			},
+			wantText: "20",
+		},
+		{
+			desc: "clamps at the minimum",
+			opts: []Option{
+				Range(20, 20000, 1),
+			},
+			keys:     []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyPgDn, keyboard.KeyArrowDown},
+			want:     []int32{1, -1},
+			wantText: "20",
+		},
+		{
+			desc: "clamps at the maximum",
+			opts: []Option{
+				Range(0, 1, 0.1),
+			},
+			keys:     []keyboard.Key{keyboard.KeyEnd, keyboard.KeyArrowDown, keyboard.KeyPgUp, keyboard.KeyArrowUp},
+			want:     []int32{10, -1, 1},
+			wantText: "1.0",
+		},
+		{
+			desc: "shows the value with the precision of the step",
+			opts: []Option{
+				Range(-1, 1, 0.25),
+			},
+			keys:     []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyArrowUp, keyboard.KeyArrowUp},
+			want:     []int32{1, 1, 1},
+			wantText: "-0.25",
+		},
+		{
+			desc: "last step stops at the maximum",
+			opts: []Option{
+				Range(0, 1, 0.3),
+			},
+			keys:     []keyboard.Key{keyboard.KeyEnd},
+			want:     []int32{4},
+			wantText: "1.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if (err != nil) != tc.wantNewErr {
+				t.Errorf("New => unexpected error: %v, wantNewErr: %v", err, tc.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, k := range tc.keys {
+				if err := d.Keyboard(&terminalapi.Keyboard{Key: k}, &widgetapi.EventMeta{Focused: true}); err != nil {
+					t.Fatalf("Keyboard(%v) => unexpected error: %v", k, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Keyboard => unexpected deltas (-want, +got):\n%s", diff)
+			}
+			if got := d.progressText(); got != tc.wantText {
+				t.Errorf("progressText => %q, want %q", got, tc.wantText)
+			}
+		})
+	}
+}
+
+func TestOscModes(t *testing.T) {
+	tests := []struct {
+		desc       string
+		opts       []Option
+		mode       []OscMode
+		keys       []keyboard.Key
+		wantNewErr bool
+		want       []interface{}
+	}{
+		{
+			desc:       "New fails on unknown mode",
+			mode:       []OscMode{OscNormalized + 1},
+			wantNewErr: true,
+		},
+		{
+			desc: "relative by default",
+			keys: []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyPgUp},
+			want: []interface{}{int32(1), int32(10)},
+		},
+		{
+			desc: "absolute int",
+			opts: []Option{
+				Range(20, 20000, 10),
+			},
+			mode: []OscMode{OscAbsoluteInt},
+			keys: []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyPgUp, keyboard.KeyEnd},
+			want: []interface{}{int32(30), int32(130), int32(20000)},
+		},
+		{
+			desc: "absolute float",
+			opts: []Option{
+				Range(-1, 1, 0.25),
+			},
+			mode: []OscMode{OscAbsoluteFloat},
+			keys: []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyEnd},
+			want: []interface{}{float32(-0.75), float32(1)},
+		},
+		{
+			desc: "normalized",
+			opts: []Option{
+				Range(20, 20000, 10),
+			},
+			mode: []OscMode{OscNormalized},
+			keys: []keyboard.Key{keyboard.KeyEnd, keyboard.KeyHome},
+			want: []interface{}{float32(1), float32(0)},
+		},
+		{
+			desc: "absolute modes stop at the ends without a range",
+			mode: []OscMode{OscNormalized},
+			keys: []keyboard.Key{keyboard.KeyEnd, keyboard.KeyArrowUp, keyboard.KeyHome, keyboard.KeyArrowDown},
+			want: []interface{}{float32(1), float32(0)},
+		},
+		{
+			desc: "sends nothing when clamped",
+			opts: []Option{
+				Range(0, 10, 1),
+			},
+			mode: []OscMode{OscAbsoluteInt},
+			keys: []keyboard.Key{keyboard.KeyArrowDown},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/param", tc.mode...)}, tc.opts...)
+			d, err := New(opts...)
+			if (err != nil) != tc.wantNewErr {
+				t.Errorf("New => unexpected error: %v, wantNewErr: %v", err, tc.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, k := range tc.keys {
+				if err := d.Keyboard(&terminalapi.Keyboard{Key: k}, &widgetapi.EventMeta{Focused: true}); err != nil {
+					t.Fatalf("Keyboard(%v) => unexpected error: %v", k, err)
+				}
+			}
+
+			got := arguments(t, rec.Messages(), "/param")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Keyboard => unexpected arguments (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opts     []Option
+		update   func(*Encoder) error
+		keys     []keyboard.Key // keys pressed after the update.
+		want     float64
+		wantText string
+	}{
+		{
+			desc:     "zero without an update",
+			want:     0,
+			wantText: "0%",
+		},
+		{
+			desc: "Percent sets the position",
+			update: func(d *Encoder) error {
+				return d.Percent(25)
+			},
+			want:     25,
+			wantText: "25%",
+		},
+		{
+			desc: "Percent sets the position within the range",
+			opts: []Option{
+				Range(20, 220, 2),
+			},
+			update: func(d *Encoder) error {
+				return d.Percent(25)
+			},
+			want:     70,
+			wantText: "70",
+		},
+		{
+			desc: "Percent applies a new range",
+			update: func(d *Encoder) error {
+				return d.Percent(50, Range(0, 10, 0.5))
+			},
+			want:     5,
+			wantText: "5.0",
+		},
+		{
+			desc: "Absolute sets the current and the total",
+			update: func(d *Encoder) error {
+				return d.Absolute(7, 10)
+			},
+			want:     7,
+			wantText: "7/10",
+		},
+		{
+			desc: "turning after Absolute moves within the total",
+			opts: []Option{
+				Range(0, 100, 1),
+			},
+			update: func(d *Encoder) error {
+				return d.Absolute(9, 10)
+			},
+			keys:     []keyboard.Key{keyboard.KeyArrowUp, keyboard.KeyArrowUp},
+			want:     10,
+			wantText: "10/10",
+		},
+		{
+			desc: "Percent after Absolute restores the range",
+			opts: []Option{
+				Range(0, 1, 0.01),
+			},
+			update: func(d *Encoder) error {
+				if err := d.Absolute(9, 10); err != nil {
+					return err
+				}
+				return d.Percent(50)
+			},
+			want:     0.5,
+			wantText: "0.50",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			if tc.update != nil {
+				if err := tc.update(d); err != nil {
+					t.Fatalf("update => unexpected error: %v", err)
+				}
+				if msgs := rec.Messages(); len(msgs) != 0 {
+					t.Errorf("update => sent %v, want no OSC messages", msgs)
+				}
+			}
+			for _, k := range tc.keys {
+				if err := d.Keyboard(&terminalapi.Keyboard{Key: k}, &widgetapi.EventMeta{Focused: true}); err != nil {
+					t.Fatalf("Keyboard(%v) => unexpected error: %v", k, err)
+				}
+			}
+
+			if got := d.Value(); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("Value => %v, want %v", got, tc.want)
+			}
+			if got := d.progressText(); got != tc.wantText {
+				t.Errorf("progressText => %q, want %q", got, tc.wantText)
+			}
+		})
+	}
+}
+
+func TestDetents(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      []Option
+		events    []terminalapi.Event
+		want      []int32
+		wantValue float64
+		wantText  string
+	}{
+		{
+			desc: "keys move by one detent and stop at the last",
+			opts: []Option{
+				Detents(3),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+				&terminalapi.Keyboard{Key: keyboard.KeyPgUp},
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			want:      []int32{1, 1},
+			wantValue: 2,
+			wantText:  "2",
+		},
+		{
+			desc: "wheel moves by one detent per event by default",
+			opts: []Option{
+				Detents(4, "a", "b", "c", "d"),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+			},
+			want:      []int32{1, 1},
+			wantValue: 2,
+			wantText:  "c",
+		},
+		{
+			desc: "wheel crosses a detent every detent steps",
+			opts: []Option{
+				Detents(4, "a", "b", "c", "d"),
+				DetentSteps(3),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+			},
+			want:      []int32{1},
+			wantValue: 1,
+			wantText:  "b",
+		},
+		{
+			desc: "change of direction restarts the detent steps",
+			opts: []Option{
+				Detents(4),
+				DetentSteps(2),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelUp},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelUp},
+			},
+			want:      []int32{1, -1},
+			wantValue: 0,
+			wantText:  "0",
+		},
+		{
+			desc: "drag crosses several detents at once",
+			opts: []Option{
+				Detents(8),
+				DetentSteps(2),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Mouse{Position: image.Point{0, 6}, Button: mouse.ButtonLeft},
+				&terminalapi.Mouse{Position: image.Point{0, 1}, Button: mouse.ButtonLeft},
+				&terminalapi.Mouse{Position: image.Point{0, 0}, Button: mouse.ButtonLeft},
+			},
+			want:      []int32{2, 1},
+			wantValue: 3,
+			wantText:  "3",
+		},
+		{
+			desc: "value within a range",
+			opts: []Option{
+				Detents(5),
+				Range(0, 1, 0.25),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			want:      []int32{1},
+			wantValue: 0.25,
+			wantText:  "0.25",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, ev := range tc.events {
+				switch e := ev.(type) {
+				case *terminalapi.Keyboard:
+					err = d.Keyboard(e, &widgetapi.EventMeta{Focused: true})
+				case *terminalapi.Mouse:
+					err = d.Mouse(e, &widgetapi.EventMeta{})
+				}
+				if err != nil {
+					t.Fatalf("event %v => unexpected error: %v", ev, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("unexpected deltas (-want, +got):\n%s", diff)
+			}
+			if got := d.Value(); math.Abs(got-tc.wantValue) > 1e-9 {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+			if got := d.progressText(); got != tc.wantText {
+				t.Errorf("progressText => %q, want %q", got, tc.wantText)
+			}
+		})
+	}
+}
+
+func TestFine(t *testing.T) {
+	wheelDown := &terminalapi.Mouse{Button: mouse.ButtonWheelDown}
+	tests := []struct {
+		desc      string
+		opts      []Option
+		mode      []OscMode
+		events    []terminalapi.Event
+		want      []interface{}
+		wantFine  bool
+		wantValue float64
+	}{
+		{
+			desc: "f toggles the fine adjustment",
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+			},
+			wantFine: true,
+		},
+		{
+			desc: "f toggles the fine adjustment off",
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				&terminalapi.Keyboard{Key: 'f'},
+				wheelDown,
+			},
+			want:      []interface{}{int32(1)},
+			wantValue: 1,
+		},
+		{
+			desc: "accumulates fractions into whole steps",
+			opts: []Option{
+				FineAdjust(0.5),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				wheelDown,
+				wheelDown,
+				wheelDown,
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			want:      []interface{}{int32(1), int32(1)},
+			wantFine:  true,
+			wantValue: 2,
+		},
+		{
+			desc: "coarse steps are not affected",
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				&terminalapi.Keyboard{Key: keyboard.KeyPgUp},
+			},
+			want:      []interface{}{int32(10)},
+			wantFine:  true,
+			wantValue: 10,
+		},
+		{
+			desc: "sends fractional deltas",
+			opts: []Option{
+				FineAdjust(0.25),
+				FineFractional(),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				wheelDown,
+				wheelDown,
+				wheelDown,
+				wheelDown,
+				&terminalapi.Mouse{Button: mouse.ButtonWheelUp},
+			},
+			want:      []interface{}{float32(0.25), float32(0.25), float32(0.25), float32(0.25), float32(-0.25)},
+			wantFine:  true,
+			wantValue: 1,
+		},
+		{
+			desc: "fractional deltas stop at the end of the range",
+			opts: []Option{
+				Range(0, 10, 1),
+				FineAdjust(0.5),
+				FineFractional(),
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelUp},
+				wheelDown,
+			},
+			want:      []interface{}{float32(0.5)},
+			wantFine:  true,
+			wantValue: 0,
+		},
+		{
+			desc: "absolute modes send the value on whole steps",
+			opts: []Option{
+				Range(0, 10, 1),
+				FineAdjust(0.5),
+				FineFractional(),
+			},
+			mode: []OscMode{OscAbsoluteInt},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				wheelDown,
+				wheelDown,
+			},
+			want:      []interface{}{int32(1)},
+			wantFine:  true,
+			wantValue: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1", tc.mode...)}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, ev := range tc.events {
+				switch e := ev.(type) {
+				case *terminalapi.Keyboard:
+					err = d.Keyboard(e, &widgetapi.EventMeta{Focused: true})
+				case *terminalapi.Mouse:
+					err = d.Mouse(e, &widgetapi.EventMeta{})
+				}
+				if err != nil {
+					t.Fatalf("event %v => unexpected error: %v", ev, err)
+				}
+			}
+
+			got := arguments(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("unexpected arguments (-want, +got):\n%s", diff)
+			}
+			if got := d.IsFine(); got != tc.wantFine {
+				t.Errorf("IsFine => %v, want %v", got, tc.wantFine)
+			}
+			if got := d.Value(); math.Abs(got-tc.wantValue) > 1e-9 {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+		})
+	}
+}
+
+// changeCall is a recorded call of a ChangeFunc.
+type changeCall struct {
+	name  string
+	delta int
+	value float64
+}
+
+func TestOnChange(t *testing.T) {
+	var calls []changeCall
+	record := func(name string) ChangeFunc {
+		return func(delta int, value float64) error {
+			calls = append(calls, changeCall{name, delta, value})
+			return nil
+		}
+	}
+
+	tests := []struct {
+		desc    string
+		opts    func() []Option
+		events  []terminalapi.Event
+		want    []changeCall
+		wantErr bool
+	}{
+		{
+			desc: "notifies about keyboard and mouse input",
+			opts: func() []Option {
+				return []Option{
+					Range(0, 10, 0.5),
+					OnChange(record("a")),
+				}
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+				&terminalapi.Mouse{Button: mouse.ButtonWheelDown},
+				&terminalapi.Keyboard{Key: keyboard.KeyPgDn},
+			},
+			want: []changeCall{
+				{"a", 1, 0.5},
+				{"a", 1, 1},
+				{"a", -2, 0},
+			},
+		},
+		{
+			desc: "notifies multiple subscribers in order",
+			opts: func() []Option {
+				return []Option{
+					OnChange(record("a")),
+					OnChange(record("b")),
+				}
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			want: []changeCall{
+				{"a", 1, 1},
+				{"b", 1, 1},
+			},
+		},
+		{
+			desc: "doesn't notify when clamped at the end of the range",
+			opts: func() []Option {
+				return []Option{
+					Range(0, 10, 1),
+					OnChange(record("a")),
+				}
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowDown},
+				&terminalapi.Keyboard{Key: 'f'},
+			},
+		},
+		{
+			desc: "fractional fine adjustments notify with zero delta",
+			opts: func() []Option {
+				return []Option{
+					FineAdjust(0.5),
+					FineFractional(),
+					OnChange(record("a")),
+				}
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: 'f'},
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			want: []changeCall{
+				{"a", 0, 0},
+				{"a", 1, 1},
+			},
+		},
+		{
+			desc: "returns the error of a subscriber",
+			opts: func() []Option {
+				return []Option{
+					OnChange(func(int, float64) error {
+						return errors.New("subscriber failed")
+					}),
+					OnChange(record("a")),
+				}
+			},
+			events: []terminalapi.Event{
+				&terminalapi.Keyboard{Key: keyboard.KeyArrowUp},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls = nil
+			d, err := New(tc.opts()...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, ev := range tc.events {
+				switch e := ev.(type) {
+				case *terminalapi.Keyboard:
+					err = d.Keyboard(e, &widgetapi.EventMeta{Focused: true})
+				case *terminalapi.Mouse:
+					err = d.Mouse(e, &widgetapi.EventMeta{})
+				}
+				if err != nil {
+					break
+				}
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("event => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+
+			if diff := pretty.Compare(tc.want, calls); diff != "" {
+				t.Errorf("unexpected calls (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOnChangeCallsBack(t *testing.T) {
+	var d *Encoder
+	var got []float64
+	d, err := New(
+		Range(0, 1, 0.25),
+		OnChange(func(int, float64) error {
+			// Must not deadlock on the encoder's lock.
+			got = append(got, d.Value())
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	if err := d.Keyboard(&terminalapi.Keyboard{Key: keyboard.KeyEnd}, &widgetapi.EventMeta{Focused: true}); err != nil {
+		t.Fatalf("Keyboard => unexpected error: %v", err)
+	}
+	if err := d.Percent(50); err != nil {
+		t.Fatalf("Percent => unexpected error: %v", err)
+	}
+
+	want := []float64{1}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("unexpected values (-want, +got):\n%s", diff)
+	}
+}
+
+func TestFeedback(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      []Option
+		mode      []OscMode
+		msgs      []*osc.Message
+		wantValue float64
+	}{
+		{
+			desc: "sets the value",
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", float32(42)),
+			},
+			wantValue: 42,
+		},
+		{
+			desc: "ignores other addresses and messages without numbers",
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", float32(42)),
+				osc.NewMessage("/other", float32(7)),
+				osc.NewMessage("/fb", "seven"),
+				osc.NewMessage("/fb"),
+			},
+			wantValue: 42,
+		},
+		{
+			desc: "sets the closest value within the range",
+			opts: []Option{
+				Range(-1, 1, 0.1),
+			},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", float64(0.52)),
+			},
+			wantValue: 0.5,
+		},
+		{
+			desc: "clamps values outside of the range",
+			opts: []Option{
+				Range(0, 10, 1),
+			},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", int32(25)),
+			},
+			wantValue: 10,
+		},
+		{
+			desc: "sets the detent",
+			opts: []Option{
+				Detents(4),
+			},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", int32(2)),
+			},
+			wantValue: 2,
+		},
+		{
+			desc: "sets the detent closest to the value within the range",
+			opts: []Option{
+				Detents(5),
+				Range(0, 1, 0.01),
+			},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", float32(0.7)),
+			},
+			wantValue: 0.75,
+		},
+		{
+			desc: "sets the normalized position",
+			opts: []Option{
+				Range(0, 2, 0.5),
+			},
+			mode: []OscMode{OscNormalized},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", float32(0.5)),
+			},
+			wantValue: 1,
+		},
+		{
+			desc: "turns by relative steps",
+			mode: []OscMode{OscRelative},
+			msgs: []*osc.Message{
+				osc.NewMessage("/fb", int32(3)),
+				osc.NewMessage("/fb", int32(-1)),
+			},
+			wantValue: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			l, err := transport.Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("transport.Listen => unexpected error: %v", err)
+			}
+			defer l.Close()
+
+			var changes int
+			rec := transport.NewRecorder()
+			opts := []Option{
+				Transport(rec),
+				OscRoute("/remote/enc/1"),
+				OnChange(func(int, float64) error {
+					changes++
+					return nil
+				}),
+				Feedback(l, "/fb", tc.mode...),
+			}
+			d, err := New(append(opts, tc.opts...)...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, msg := range tc.msgs {
+				l.Dispatch(msg)
+			}
+
+			if got := d.Value(); math.Abs(got-tc.wantValue) > 1e-9 {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+			if msgs := rec.Messages(); len(msgs) != 0 {
+				t.Errorf("feedback sent %d messages to the OSC route, want none", len(msgs))
+			}
+			if changes != 0 {
+				t.Errorf("feedback called OnChange %d times, want none", changes)
+			}
+		})
+	}
+}
+
+func TestTakeover(t *testing.T) {
+	up := &terminalapi.Keyboard{Key: keyboard.KeyArrowUp}
+	down := &terminalapi.Keyboard{Key: keyboard.KeyArrowDown}
+	feedback := func(v float32) *osc.Message {
+		return osc.NewMessage("/fb", v)
+	}
+
+	tests := []struct {
+		desc      string
+		takeover  TakeoverMode
+		percent   int
+		events    []interface{}
+		want      []interface{}
+		wantValue float64
+		wantGhost bool
+	}{
+		{
+			desc:      "none sets the encoder to the received value",
+			takeover:  TakeoverNone,
+			events:    []interface{}{feedback(5), up},
+			want:      []interface{}{float32(6)},
+			wantValue: 6,
+		},
+		{
+			desc:      "jump keeps the encoder and sends its value",
+			takeover:  TakeoverJump,
+			events:    []interface{}{feedback(5), up, up},
+			want:      []interface{}{float32(1), float32(2)},
+			wantValue: 2,
+		},
+		{
+			desc:      "jump draws the ghost until turned",
+			takeover:  TakeoverJump,
+			events:    []interface{}{feedback(5)},
+			wantValue: 0,
+			wantGhost: true,
+		},
+		{
+			desc:      "pickup doesn't send until the encoder reaches the received value",
+			takeover:  TakeoverPickup,
+			events:    []interface{}{feedback(3), up, up, up, up},
+			want:      []interface{}{float32(3), float32(4)},
+			wantValue: 4,
+		},
+		{
+			desc:      "pickup picks up when crossing the received value",
+			takeover:  TakeoverPickup,
+			events:    []interface{}{feedback(3), &terminalapi.Keyboard{Key: keyboard.KeyEnd}},
+			want:      []interface{}{float32(10)},
+			wantValue: 10,
+		},
+		{
+			desc:      "pickup from above",
+			takeover:  TakeoverPickup,
+			percent:   80,
+			events:    []interface{}{feedback(6), down, down, down},
+			want:      []interface{}{float32(6), float32(5)},
+			wantValue: 5,
+		},
+		{
+			desc:      "pickup keeps the ghost while turning away",
+			takeover:  TakeoverPickup,
+			percent:   50,
+			events:    []interface{}{feedback(3), up, up},
+			wantValue: 7,
+			wantGhost: true,
+		},
+		{
+			desc:      "pickup is immediate when the received value matches",
+			takeover:  TakeoverPickup,
+			percent:   30,
+			events:    []interface{}{feedback(3), up},
+			want:      []interface{}{float32(4)},
+			wantValue: 4,
+		},
+		{
+			desc:      "scale converges toward the end turned to",
+			takeover:  TakeoverScale,
+			events:    []interface{}{feedback(5), up, up},
+			want:      []interface{}{float32(5.5), float32(6)},
+			wantValue: 2,
+			wantGhost: true,
+		},
+		{
+			desc:      "scale takes over at the end",
+			takeover:  TakeoverScale,
+			events:    []interface{}{feedback(5), up, &terminalapi.Keyboard{Key: keyboard.KeyEnd}, down},
+			want:      []interface{}{float32(5.5), float32(10), float32(9)},
+			wantValue: 9,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			d, err := New(
+				Range(0, 10, 1),
+				Transport(rec),
+				OscRoute("/param", OscAbsoluteFloat),
+				Feedback(nil, "/fb"),
+				Takeover(tc.takeover),
+			)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+
+			for _, ev := range tc.events {
+				switch e := ev.(type) {
+				case *osc.Message:
+					d.receive(e)
+				case *terminalapi.Keyboard:
+					if err := d.Keyboard(e, &widgetapi.EventMeta{Focused: true}); err != nil {
+						t.Fatalf("Keyboard => unexpected error: %v", err)
+					}
+				}
+			}
+
+			got := arguments(t, rec.Messages(), "/param")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("unexpected arguments (-want, +got):\n%s", diff)
+			}
+			if got := d.Value(); math.Abs(got-tc.wantValue) > 1e-9 {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+			if got := d.hasRemote && !d.pickedUp; got != tc.wantGhost {
+				t.Errorf("ghost drawn => %v, want %v", got, tc.wantGhost)
+			}
+		})
+	}
+}
+
+func TestFeedbackRebinds(t *testing.T) {
+	l, err := transport.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("transport.Listen => unexpected error: %v", err)
+	}
+	defer l.Close()
+
+	d, err := New(Feedback(l, "/a"))
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	if err := d.Percent(0, Feedback(l, "/b")); err != nil {
+		t.Fatalf("Percent => unexpected error: %v", err)
+	}
+
+	l.Dispatch(osc.NewMessage("/a", float32(10)))
+	if got := d.Value(); got != 0 {
+		t.Errorf("Value after the old address => %v, want 0", got)
+	}
+	l.Dispatch(osc.NewMessage("/b", float32(20)))
+	if got := d.Value(); got != 20 {
+		t.Errorf("Value after the new address => %v, want 20", got)
+	}
+}
+
+func TestBipolar(t *testing.T) {
+	type step struct {
+		at time.Duration
+		m  *terminalapi.Mouse
+	}
+	press := func(at time.Duration, x, y int) step {
+		return step{at, &terminalapi.Mouse{Position: image.Point{x, y}, Button: mouse.ButtonLeft}}
+	}
+	release := func(at time.Duration) step {
+		return step{at, &terminalapi.Mouse{Button: mouse.ButtonRelease}}
+	}
+
+	tests := []struct {
+		desc      string
+		opts      []Option
+		percent   int
+		steps     []step
+		want      []int32
+		wantValue float64
+		wantText  string
+		// wantLike are the options of an encoder at likePercent that is drawn
+		// the same, if any.
+		wantLike    []Option
+		likePercent int
+	}{
+		{
+			desc: "shows the sign of positive values",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent:   75,
+			wantValue: 0.5,
+			wantText:  "+0.5",
+		},
+		{
+			desc: "shows the sign of negative values",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent:   25,
+			wantValue: -0.5,
+			wantText:  "-0.5",
+		},
+		{
+			desc: "draws a full circle at the negative end",
+			opts: []Option{
+				Range(-10, 0, 1),
+				Bipolar(),
+				HideTextProgress(),
+			},
+			wantValue:   -10,
+			wantText:    "-10",
+			wantLike:    []Option{Range(-10, 0, 1), HideTextProgress()},
+			likePercent: 100,
+		},
+		{
+			desc: "double click turns back to zero",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent: 100,
+			steps: []step{
+				press(0, 3, 3),
+				release(50 * time.Millisecond),
+				press(100*time.Millisecond, 3, 3),
+				release(150 * time.Millisecond),
+			},
+			want:     []int32{-2},
+			wantText: "0.0",
+		},
+		{
+			desc: "slow clicks don't reset",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent: 25,
+			steps: []step{
+				press(0, 3, 3),
+				release(50 * time.Millisecond),
+				press(time.Second, 3, 3),
+				release(1050 * time.Millisecond),
+			},
+			wantValue: -0.5,
+			wantText:  "-0.5",
+		},
+		{
+			desc: "clicks at different positions don't reset",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent: 25,
+			steps: []step{
+				press(0, 3, 3),
+				release(50 * time.Millisecond),
+				press(100*time.Millisecond, 4, 3),
+				release(150 * time.Millisecond),
+			},
+			wantValue: -0.5,
+			wantText:  "-0.5",
+		},
+		{
+			desc: "double click doesn't reset a unipolar encoder",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+			},
+			percent: 25,
+			steps: []step{
+				press(0, 3, 3),
+				release(50 * time.Millisecond),
+				press(100*time.Millisecond, 3, 3),
+				release(150 * time.Millisecond),
+			},
+			wantValue: -0.5,
+			wantText:  "-0.5",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(image.Rect(0, 0, 7, 7))
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			if tc.wantLike != nil {
+				want := drawn(t, c.Area(), tc.likePercent, tc.wantLike...)
+				if diff := pretty.Compare(want, drawn(t, c.Area(), tc.percent, tc.opts...)); diff != "" {
+					t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+				}
+			}
+
+			start := time.Now()
+			for _, s := range tc.steps {
+				d.now = func() time.Time { return start.Add(s.at) }
+				if err := d.Mouse(s.m, &widgetapi.EventMeta{}); err != nil {
+					t.Fatalf("Mouse(%v) => unexpected error: %v", s.m, err)
+				}
+			}
+
+			got := deltas(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Mouse => unexpected deltas (-want, +got):\n%s", diff)
+			}
+			if got := d.Value(); got != tc.wantValue {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+			if got := d.progressText(); got != tc.wantText {
+				t.Errorf("progressText => %q, want %q", got, tc.wantText)
+			}
+		})
+	}
+}
+
+// drawn returns the rows of a new encoder with the options at the percentage
+// drawn in the area.
+func drawn(t *testing.T, ar image.Rectangle, percent int, opts ...Option) []string {
+	t.Helper()
+	d, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	if err := d.Percent(percent); err != nil {
+		t.Fatalf("Percent => unexpected error: %v", err)
+	}
+	c, err := canvas.New(ar)
+	if err != nil {
+		t.Fatalf("canvas.New => unexpected error: %v", err)
+	}
+	if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+		t.Fatalf("Draw => unexpected error: %v", err)
+	}
+	ft := faketerm.MustNew(c.Size())
+	if err := c.Apply(ft); err != nil {
+		t.Fatalf("Apply => unexpected error: %v", err)
+	}
+	return rows(ft)
+}
+
+func TestReset(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      []Option
+		percent   int
+		fine      bool
+		ups       int
+		remote    *osc.Message
+		want      []interface{}
+		wantValue float64
+	}{
+		{
+			desc:      "turns to the lower bound",
+			opts:      []Option{Range(-1, 1, 0.5)},
+			percent:   75,
+			want:      []interface{}{int32(-3)},
+			wantValue: -1,
+		},
+		{
+			desc: "turns a bipolar encoder to zero",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+			},
+			percent: 25,
+			want:    []interface{}{int32(1)},
+		},
+		{
+			desc:      "turns an encoder that wraps around back by what it sent",
+			ups:       105,
+			want:      []interface{}{int32(-105)},
+			wantValue: 0,
+		},
+		{
+			desc: "turns back by the fractions sent with the fine adjustment",
+			opts: []Option{
+				FineAdjust(0.25),
+				FineFractional(),
+			},
+			fine:      true,
+			ups:       6,
+			want:      []interface{}{float32(-1.5)},
+			wantValue: 0,
+		},
+		{
+			desc:      "sends nothing at rest",
+			opts:      []Option{Range(-1, 1, 0.5)},
+			wantValue: -1,
+		},
+		{
+			desc: "sends the lower bound before the remote value was taken over",
+			opts: []Option{
+				Range(0, 10, 1),
+				OscRoute("/remote/enc/1", OscAbsoluteFloat),
+				Feedback(nil, "/fb"),
+				Takeover(TakeoverPickup),
+			},
+			percent: 50,
+			remote:  osc.NewMessage("/fb", float32(8)),
+			want:    []interface{}{float32(0)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/enc/1")}, tc.opts...)
+			d, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+			if tc.remote != nil {
+				d.receive(tc.remote)
+			}
+			d.Fine(tc.fine)
+			up := &terminalapi.Keyboard{Key: keyboard.KeyArrowUp}
+			for i := 0; i < tc.ups; i++ {
+				if err := d.Keyboard(up, &widgetapi.EventMeta{Focused: true}); err != nil {
+					t.Fatalf("Keyboard => unexpected error: %v", err)
+				}
+			}
+			rec.Reset()
+
+			if err := d.Reset(); err != nil {
+				t.Fatalf("Reset => unexpected error: %v", err)
+			}
+			got := arguments(t, rec.Messages(), "/remote/enc/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Reset => unexpected arguments (-want, +got):\n%s", diff)
+			}
+			if got := d.Value(); got != tc.wantValue {
+				t.Errorf("Value => %v, want %v", got, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	l, err := transport.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("transport.Listen => unexpected error: %v", err)
+	}
+	defer l.Close()
+	received := make(chan *osc.Message, 10)
+	l.Handle("/remote/enc/1", func(msg *osc.Message) {
+		received <- msg
+	})
+
+	port := l.Addr().(*net.UDPAddr).Port
+	d, err := New(OscRoute("/remote/enc/1"), OscTarget("127.0.0.1", port))
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	up := &terminalapi.Keyboard{Key: keyboard.KeyArrowUp}
+	if err := d.Keyboard(up, &widgetapi.EventMeta{Focused: true}); err != nil {
+		t.Fatalf("Keyboard => unexpected error: %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	// Closed encoders don't send anymore.
+	if err := d.Keyboard(up, &widgetapi.EventMeta{Focused: true}); err != nil {
+		t.Fatalf("Keyboard => unexpected error: %v", err)
+	}
+
+	select {
+	case msg := <-received:
+		if diff := pretty.Compare([]interface{}{int32(1)}, msg.Arguments); diff != "" {
+			t.Errorf("unexpected arguments (-want, +got):\n%s", diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("received nothing before the timeout, want the turn")
 	}
-	if err := d.Mouse(&terminalapi.Mouse{}, &widgetapi.EventMeta{}); err == nil {
-		t.Errorf("Mouse => got nil err, wanted one")
+	select {
+	case msg := <-received:
+		t.Errorf("received %v after Close, want nothing", msg)
+	case <-time.After(50 * time.Millisecond):
 	}
 }
 
 func TestOptions(t *testing.T) {
-	d, err := New()
+	d, err := New(OscRoute("/remote/enc/1"), OscTarget("127.0.0.1", 10111))
 	if err != nil {
 		t.Fatalf("New => unexpected error: %v", err)
 	}
@@ -907,9 +3090,8 @@ func TestOptions(t *testing.T) {
 	got := d.Options()
 	want := widgetapi.Options{
 		Ratio:        image.Point{4, 2},
-		MinimumSize:  image.Point{3, 3},
-		WantKeyboard: widgetapi.KeyScopeNone,
-		WantMouse:    widgetapi.MouseScopeNone,
+		WantKeyboard: widgetapi.KeyScopeFocused,
+		WantMouse:    widgetapi.MouseScopeGlobal,
 	}
 	if diff := pretty.Compare(want, got); diff != "" {
 		t.Errorf("Options => unexpected diff (-want, +got):\n%s", diff)
@@ -18,9 +18,12 @@ package encoder
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/mum4k/termdash/align"
 	"github.com/mum4k/termdash/cell"
+	"github.com/zzsnzmn/osctl/internal/transport"
 )
 
 // Option is used to provide options.
@@ -54,14 +57,76 @@ type options struct {
 	// The direction in which the encoder completes as progress increases.
 	// Positive for counter-clockwise, negative for clockwise.
 	direction int
-
-	// TODO: add osc fields here
-	lowerBound int
-	upperBound int
+	// The angle in degrees from the start angle that represents 100% of the
+	// progress.
+	sweep int
+	// How the progress is indicated on the encoder.
+	indicator indicator
+
+	// The number of steps the encoder turns on PgUp and PgDn.
+	coarseSteps int
+
+	// How dragging the mouse on the encoder turns it.
+	dragMode dragMode
+	// The number of steps the encoder turns per cell of a vertical drag.
+	dragSteps int
+
+	// Acceleration of fast successive steps, disabled if accelCurve is nil.
+	accelCurve  AccelCurve
+	accelWindow time.Duration
+	accelMax    int
+
+	// The fraction of a step the encoder turns by while the fine adjustment
+	// is on and whether it is sent as a fractional delta.
+	fineFactor     float64
+	fineFractional bool
+	fineCellOpts   []cell.Option
+
+	// The number of detents the encoder snaps to, zero if it doesn't have
+	// any, with optional labels for each one.
+	detents      int
+	detentLabels []string
+	// The number of steps of mouse movement needed to move by one detent.
+	detentSteps int
+
+	// The range of values the encoder moves through in steps. Encoders
+	// without a range wrap around unless they send absolute values, bounded
+	// ones are clamped.
+	bounded    bool
+	lowerBound float64
+	upperBound float64
+	step       float64
+	// Bipolar encoders draw zero at the top.
+	bipolar bool
 
 	oscRoute string
-	oscAddr  string
-	oscPort  int
+	oscMode  OscMode
+	// The host and port the OSC route is sent to over UDP unless a transport
+	// was provided.
+	hasTarget bool
+	oscAddr   string
+	oscPort   int
+	transport transport.Transport
+
+	// The listener and address of the values pushed back by the receiver.
+	feedback      *transport.Listener
+	feedbackAddr  string
+	feedbackMode  OscMode
+	takeover      TakeoverMode
+	ghostCellOpts []cell.Option
+
+	style Style
+	// Draw with plain characters instead of braille.
+	ascii bool
+	// The listener, serialosc prefix and ring number of the messages that
+	// set the LED ring.
+	ring       *transport.Listener
+	ringPrefix string
+	ringN      int
+	ringColors [RingLevels]cell.Color
+
+	// Functions notified when the encoder turns.
+	onChange []ChangeFunc
 }
 
 // validate validates the provided options.
@@ -74,27 +139,138 @@ func (o *options) validate() error {
 		return fmt.Errorf("invalid start angle %d, must be in range %d <= angle < %d", o.startAngle, min, max)
 	}
 
-	if o.oscRoute == "" {
-		return fmt.Errorf("invalid osc route %s", o.oscRoute)
+	if min, max := 1, 360; o.sweep < min || o.sweep > max {
+		return fmt.Errorf("invalid sweep %d, must be in range %d <= sweep <= %d", o.sweep, min, max)
+	}
+
+	if o.coarseSteps < 1 {
+		return fmt.Errorf("invalid coarse steps %d, must be 1 or more", o.coarseSteps)
+	}
+
+	if o.dragSteps < 1 {
+		return fmt.Errorf("invalid drag steps %d, must be 1 or more", o.dragSteps)
+	}
+
+	if o.accelCurve != nil {
+		if o.accelWindow <= 0 {
+			return fmt.Errorf("invalid acceleration window %v, must be positive", o.accelWindow)
+		}
+		if o.accelMax < 1 {
+			return fmt.Errorf("invalid acceleration multiplier %d, must be 1 or more", o.accelMax)
+		}
+	}
+
+	if o.lowerBound >= o.upperBound {
+		return fmt.Errorf("invalid range, min(%v) must be less than max(%v)", o.lowerBound, o.upperBound)
+	}
+	if o.step <= 0 || o.step > o.upperBound-o.lowerBound {
+		return fmt.Errorf("invalid step %v, must be in range 0 < step <= %v", o.step, o.upperBound-o.lowerBound)
+	}
+	if o.bipolar && (!o.bounded || o.lowerBound > 0 || o.upperBound < 0) {
+		return fmt.Errorf("bipolar encoders require a range that includes zero")
+	}
+
+	if o.fineFactor <= 0 || o.fineFactor > 1 {
+		return fmt.Errorf("invalid fine factor %v, must be in range 0 < f <= 1", o.fineFactor)
+	}
+
+	if o.detents != 0 && o.detents < 2 {
+		return fmt.Errorf("invalid number of detents %d, must be 2 or more", o.detents)
+	}
+	if l := len(o.detentLabels); l != 0 && l != o.detents {
+		return fmt.Errorf("invalid number of detent labels %d, must match the %d detents", l, o.detents)
+	}
+	if o.detentSteps < 1 {
+		return fmt.Errorf("invalid detent steps %d, must be 1 or more", o.detentSteps)
+	}
+
+	if o.oscMode < OscRelative || o.oscMode > OscNormalized {
+		return fmt.Errorf("invalid osc mode %d", o.oscMode)
+	}
+	if o.feedbackMode < OscRelative || o.feedbackMode > OscNormalized {
+		return fmt.Errorf("invalid feedback mode %d", o.feedbackMode)
+	}
+	if o.style < StyleKnob || o.style > StyleNumeric {
+		return fmt.Errorf("invalid style %d", o.style)
+	}
+	if o.ringN < 0 {
+		return fmt.Errorf("invalid ring number %d, must be 0 or more", o.ringN)
+	}
+	if o.takeover < TakeoverNone || o.takeover > TakeoverScale {
+		return fmt.Errorf("invalid takeover mode %d", o.takeover)
+	}
+	if o.takeover != TakeoverNone && o.oscRoute != "" && o.oscMode == OscRelative {
+		return fmt.Errorf("soft takeover requires an absolute OSC mode")
+	}
+	if o.hasTarget && (o.oscPort < 1 || o.oscPort > 65535) {
+		return fmt.Errorf("invalid OSC target port %d, must be in range 1 <= port <= 65535", o.oscPort)
+	}
+	if o.oscRoute != "" && o.transport == nil && !o.hasTarget {
+		return fmt.Errorf("OSC route %q requires a target or a transport", o.oscRoute)
+	}
+	for i, fn := range o.onChange {
+		if fn == nil {
+			return fmt.Errorf("invalid OnChange function %d, must not be nil", i)
+		}
 	}
 
 	return nil
 }
 
+// steps returns the number of steps between the lower and the upper bound or
+// between the first and the last detent.
+func (o *options) steps() int {
+	if o.detents > 0 {
+		return o.detents - 1
+	}
+	// Allow for rounding errors when the range is a multiple of the step.
+	return int(math.Ceil((o.upperBound-o.lowerBound)/o.step - 1e-9))
+}
+
+// clamped asserts whether the encoder stops at the ends instead of wrapping
+// around. Encoders that send absolute values are clamped even without a
+// range, wrapping around would make the receiver jump from one end to the
+// other.
+func (o *options) clamped() bool {
+	return o.bounded || o.detents > 0 || o.oscMode != OscRelative
+}
+
+// precision returns the number of decimal places needed to display values
+// that are multiples of the step.
+func (o *options) precision() int {
+	p := 0
+	for s := o.step; p < 6 && math.Abs(s-math.Round(s)) > 1e-9; s *= 10 {
+		p++
+	}
+	return p
+}
+
 // newOptions returns options with the default values set.
 func newOptions() *options {
 	return &options{
 		centerPercent: DefaultCenterPercent,
 		startAngle:    DefaultStartAngle,
 		direction:     -1,
+		sweep:         DefaultSweep,
+		coarseSteps:   DefaultCoarseSteps,
+		dragSteps:     DefaultDragSteps,
+		lowerBound:    0,
+		upperBound:    100,
+		step:          1,
+		detentSteps:   DefaultDetentSteps,
+		fineFactor:    DefaultFineFactor,
+		fineCellOpts: []cell.Option{
+			cell.FgColor(cell.ColorYellow),
+		},
+		ghostCellOpts: []cell.Option{
+			cell.FgColor(cell.ColorRed),
+		},
+		ringColors: defaultRingColors(),
 		textCellOpts: []cell.Option{
 			cell.FgColor(cell.ColorDefault),
 			cell.BgColor(cell.ColorDefault),
 		},
 		labelAlign: DefaultLabelAlign,
-		oscAddr:    "localhost", // make this configured...
-		oscPort:    10111,
-		oscRoute:   "",
 	}
 }
 
@@ -129,6 +305,7 @@ func ShowTextProgress() Option {
 }
 
 // HideTextProgress disables the display of a text enumerating the progress.
+// The one line drawn when the area is too small still shows it.
 func HideTextProgress() Option {
 	return option(func(opts *options) {
 		opts.hideTextProgress = true
@@ -179,6 +356,46 @@ func CounterClockwise() Option {
 	})
 }
 
+// DefaultSweep is the default value for the Sweep option.
+const DefaultSweep = 360
+
+// Sweep sets the size of the angle in degrees from the start angle that
+// represents 100% of the progress. The default is a full circle, a smaller
+// sweep leaves a gap like on a potentiometer, e.g. StartAngle(240), Sweep(300)
+// and Clockwise() turns from 7 o'clock to 5 o'clock.
+// Valid values are in range 1 <= sweep <= 360.
+func Sweep(degrees int) Option {
+	return option(func(opts *options) {
+		opts.sweep = degrees
+	})
+}
+
+// indicator determines how the progress is indicated on the encoder.
+type indicator int
+
+const (
+	// indicatorArc fills the circle from the start angle to the progress.
+	indicatorArc indicator = iota
+	// indicatorPointer draws the full sweep with a notch at the progress.
+	indicatorPointer
+)
+
+// IndicatorArc configures the encoder to indicate the progress by a partial
+// circle filled from the start angle. This is the default option.
+func IndicatorArc() Option {
+	return option(func(opts *options) {
+		opts.indicator = indicatorArc
+	})
+}
+
+// IndicatorPointer configures the encoder to indicate the progress by a notch
+// in a circle that covers the full sweep, like the pointer on a knob.
+func IndicatorPointer() Option {
+	return option(func(opts *options) {
+		opts.indicator = indicatorPointer
+	})
+}
+
 // Label sets a text label to be displayed under the encoder.
 func Label(text string, cOpts ...cell.Option) Option {
 	return option(func(opts *options) {
@@ -187,14 +404,397 @@ func Label(text string, cOpts ...cell.Option) Option {
 	})
 }
 
-func OscRoute(route, addr string, port int) Option {
+// DefaultFineFactor is the default value for the FineAdjust option.
+const DefaultFineFactor = 0.1
+
+// FineAdjust sets the fraction of a step the encoder turns by for every step
+// of keyboard or mouse input while the fine adjustment is on.
+// By default the fractions are accumulated and whole steps are sent once they
+// add up, use FineFractional to send the fractions.
+// Valid values are in range 0 < f <= 1.
+func FineAdjust(factor float64) Option {
+	return option(func(opts *options) {
+		opts.fineFactor = factor
+	})
+}
+
+// FineFractional configures an encoder in the OscRelative mode to send the
+// fractional deltas as float32 while the fine adjustment is on, e.g. 0.1 for
+// every step. Has no effect on encoders with detents.
+func FineFractional() Option {
+	return option(func(opts *options) {
+		opts.fineFractional = true
+	})
+}
+
+// FineCellOpts sets cell options on cells that contain the encoder while the
+// fine adjustment is on. Defaults to a yellow foreground.
+func FineCellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.fineCellOpts = cOpts
+	})
+}
+
+// Detents makes the encoder snap to n positions for discrete selections, e.g.
+// scales or sample slots. The encoder stops at the first and the last detent,
+// each detent is marked by a tick around the encoder and every crossed detent
+// is one step sent to the OSC route.
+// The value is the index of the detent, or evenly distributed between min and
+// max if combined with Range.
+// If labels are provided, there must be one for each detent and the displayed
+// text shows the label of the current detent.
+// Valid values of n are 2 or more.
+func Detents(n int, labels ...string) Option {
+	return option(func(opts *options) {
+		opts.detents = n
+		opts.detentLabels = labels
+	})
+}
+
+// DefaultDetentSteps is the default value for the DetentSteps option.
+const DefaultDetentSteps = 1
+
+// DetentSteps sets how many mouse wheel events or cells of vertical drag in the
+// same direction move the encoder by one detent, regardless of how fast they
+// arrive. Keyboard steps always move by one detent.
+// Must be 1 or more.
+func DetentSteps(n int) Option {
+	return option(func(opts *options) {
+		opts.detentSteps = n
+	})
+}
+
+// OscMode determines what the encoder sends to its OSC route when it turns.
+// Encoders in the absolute modes stop at the ends, also without Range().
+type OscMode int
+
+const (
+	// OscRelative sends the change in steps as an int32, e.g. for
+	// /remote/enc/N on norns. This is the default mode.
+	OscRelative OscMode = iota
+	// OscAbsoluteInt sends the current value rounded to an int32.
+	OscAbsoluteInt
+	// OscAbsoluteFloat sends the current value as a float32.
+	OscAbsoluteFloat
+	// OscNormalized sends the position of the current value within the range
+	// as a float32 in range 0 <= v <= 1.
+	OscNormalized
+)
+
+// OscRoute sets the OSC address the encoder sends to when it turns. Encoders
+// without a route don't send anything. The messages are sent to the receiver
+// set by OscTarget() or with the transport provided to Transport().
+// The optional mode selects what is sent, defaults to OscRelative.
+func OscRoute(route string, mode ...OscMode) Option {
 	return option(func(opts *options) {
 		opts.oscRoute = route
+		opts.oscMode = OscRelative
+		if len(mode) > 0 {
+			opts.oscMode = mode[0]
+		}
+	})
+}
+
+// OscTarget sets the host and port of the receiver of the OSC route. The
+// messages are sent asynchronously over UDP, from one queue and connection
+// shared by all the encoders and keys sending to the host and port. When the
+// receiver can't keep up, relative deltas are added up and absolute values
+// replaced by the latest one. Call Close() to send the messages still queued
+// before exiting.
+// Ignored when a transport is provided with Transport().
+func OscTarget(addr string, port int) Option {
+	return option(func(opts *options) {
+		opts.hasTarget = true
 		opts.oscAddr = addr
 		opts.oscPort = port
 	})
 }
 
+// Transport sets the transport the OSC messages are sent with instead of UDP
+// to the host and port set by OscTarget(). Allows sharing one transport
+// between multiple controls or recording the messages in tests.
+// The encoder doesn't close the transport.
+func Transport(t transport.Transport) Option {
+	return option(func(opts *options) {
+		opts.transport = t
+	})
+}
+
+// Feedback makes the encoder display the values the listener receives on the
+// address, so that the receiver can push its state back. The first argument
+// of the messages is used, the optional mode selects how it is interpreted and
+// defaults to OscAbsoluteFloat:
+//   - OscAbsoluteInt and OscAbsoluteFloat set the value.
+//   - OscNormalized sets the position within the range, 0 <= v <= 1.
+//   - OscRelative turns the encoder by the number of steps.
+//
+// Received values are neither sent to the OSC route nor passed to the OnChange
+// functions. Values outside of the range are clamped. See Takeover() for
+// keeping the encoder where it is instead.
+func Feedback(l *transport.Listener, address string, mode ...OscMode) Option {
+	return option(func(opts *options) {
+		opts.feedback = l
+		opts.feedbackAddr = address
+		opts.feedbackMode = OscAbsoluteFloat
+		if len(mode) > 0 {
+			opts.feedbackMode = mode[0]
+		}
+	})
+}
+
+// TakeoverMode determines how the encoder takes over from the value received
+// with Feedback() when they differ, e.g. after the parameter was changed on the
+// receiver itself.
+type TakeoverMode int
+
+const (
+	// TakeoverNone sets the encoder to the received value. This is the
+	// default mode.
+	TakeoverNone TakeoverMode = iota
+	// TakeoverJump keeps the encoder where it is and the received value jumps
+	// to it on the next turn.
+	TakeoverJump
+	// TakeoverPickup keeps the encoder where it is and doesn't send anything
+	// until it reaches or crosses the received value.
+	TakeoverPickup
+	// TakeoverScale keeps the encoder where it is and sends a value that moves
+	// toward the end the encoder turns to by the same proportion, so that the
+	// two converge without a jump.
+	TakeoverScale
+)
+
+// Takeover sets how the encoder takes over from the values received with
+// Feedback(). Until the encoder took over, the received value is drawn as a
+// ghost mark on the circle. Requires an absolute OSC mode when the encoder has
+// an OSC route.
+func Takeover(mode TakeoverMode) Option {
+	return option(func(opts *options) {
+		opts.takeover = mode
+	})
+}
+
+// GhostCellOpts sets the cell options of the mark of the received value that
+// wasn't taken over yet. Defaults to a red foreground.
+func GhostCellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.ghostCellOpts = cOpts
+	})
+}
+
+// Style determines how the encoder is drawn.
+type Style int
+
+const (
+	// StyleKnob draws the encoder as a knob, a circle that indicates the
+	// value. This is the default style.
+	StyleKnob Style = iota
+	// StyleRing draws the encoder as the LED ring of a monome arc, 64
+	// segments around the circle each lit at one of 16 levels. The levels are
+	// set with SetRing() or by the messages bound with RingOSC(), turning the
+	// encoder doesn't change them.
+	StyleRing
+	// StyleNeedle draws the encoder as a knob with a needle that points at
+	// the value from the middle of the circle.
+	StyleNeedle
+	// StyleBarHorizontal draws the value as a meter that fills from the left,
+	// or from zero when Bipolar(). Doesn't need a square area like the
+	// circular styles.
+	StyleBarHorizontal
+	// StyleBarVertical draws the value as a meter that fills from the bottom,
+	// or from zero when Bipolar().
+	StyleBarVertical
+	// StyleNumeric draws only the displayed text, it fits into a single cell
+	// and doesn't rely on braille characters. The text is drawn even with
+	// HideTextProgress().
+	StyleNumeric
+)
+
+// circular asserts whether the style is drawn as a circle on a braille canvas.
+func (s Style) circular() bool {
+	return s == StyleKnob || s == StyleRing || s == StyleNeedle
+}
+
+// DrawStyle sets how the encoder is drawn, defaults to StyleKnob.
+// All the styles share the value and the input handling, SetStyle() switches
+// the style of an existing encoder.
+func DrawStyle(s Style) Option {
+	return option(func(opts *options) {
+		opts.style = s
+	})
+}
+
+// RingOSC sets the LED ring from the serialosc messages the listener receives
+// for ring n, with the prefix, e.g. "/monome" for "/monome/ring/map".
+// The messages /ring/set, /ring/all, /ring/map and /ring/range are supported.
+func RingOSC(l *transport.Listener, prefix string, n int) Option {
+	return option(func(opts *options) {
+		opts.ring = l
+		opts.ringPrefix = prefix
+		opts.ringN = n
+	})
+}
+
+// RingColors sets the colors of the LED levels, indexed by the level. Level 0
+// is off and isn't drawn. Defaults to shades of grey getting brighter with the
+// level.
+func RingColors(colors [RingLevels]cell.Color) Option {
+	return option(func(opts *options) {
+		opts.ringColors = colors
+	})
+}
+
+// defaultRingColors returns the default colors of the LED levels, shades of
+// grey from the 256 color palette.
+func defaultRingColors() [RingLevels]cell.Color {
+	var colors [RingLevels]cell.Color
+	for l := 1; l < RingLevels; l++ {
+		colors[l] = cell.ColorNumber(236 + (l-1)*19/(RingLevels-2))
+	}
+	return colors
+}
+
+// ASCII draws the encoder with plain ASCII characters instead of braille, for
+// terminals and fonts that can't display braille, e.g. the Linux console.
+// The encoder is drawn as a meter, e.g. "[====|    ]", with the displayed text
+// below it. StyleBarVertical draws a column of the meter without the text,
+// StyleRing draws the LED levels along the meter and StyleNumeric is drawn as
+// usual. The encoder asks for the same area as with braille.
+// See BrailleSupported() to choose the fallback automatically.
+func ASCII() Option {
+	return option(func(opts *options) {
+		opts.ascii = true
+	})
+}
+
+// ChangeFunc is called when the encoder turns with the change in whole steps
+// and the value after the change. The delta is zero when a fractional fine
+// adjustment didn't add up to a whole step, see FineFractional().
+type ChangeFunc func(delta int, value float64) error
+
+// OnChange adds a function that is called each time the encoder is turned by
+// keyboard or mouse input, after the OSC route was sent to. Can be provided
+// multiple times, the functions are called in the order they were added.
+// The functions are called without holding the encoder's lock, so they can
+// call its methods. An error returned by a function is returned from the
+// Keyboard or Mouse call that turned the encoder.
+// Setting the progress with Percent() or Absolute() doesn't call the
+// functions.
+func OnChange(fn ChangeFunc) Option {
+	return option(func(opts *options) {
+		opts.onChange = append(opts.onChange, fn)
+	})
+}
+
+// DefaultCoarseSteps is the default value for the CoarseSteps option.
+const DefaultCoarseSteps = 10
+
+// CoarseSteps sets the number of steps the encoder turns when PgUp or PgDn
+// is pressed while it is focused. Must be 1 or more.
+func CoarseSteps(n int) Option {
+	return option(func(opts *options) {
+		opts.coarseSteps = n
+	})
+}
+
+// dragMode determines how dragging the mouse turns the encoder.
+type dragMode int
+
+const (
+	// dragVertical turns the encoder up when dragging upwards.
+	dragVertical dragMode = iota
+	// dragAngular turns the encoder by rotating around its middle.
+	dragAngular
+)
+
+// DragVertical configures the encoder to turn up when the mouse is dragged
+// upwards and down when dragged downwards. This is the default option.
+func DragVertical() Option {
+	return option(func(opts *options) {
+		opts.dragMode = dragVertical
+	})
+}
+
+// DragAngular configures the encoder to turn when the mouse is dragged around
+// its middle, up when dragged clockwise and down when dragged
+// counter-clockwise. A full revolution turns the encoder through its total.
+func DragAngular() Option {
+	return option(func(opts *options) {
+		opts.dragMode = dragAngular
+	})
+}
+
+// DefaultDragSteps is the default value for the DragSteps option.
+const DefaultDragSteps = 1
+
+// DragSteps sets the number of steps the encoder turns for every cell the
+// mouse is dragged vertically. Must be 1 or more.
+func DragSteps(n int) Option {
+	return option(func(opts *options) {
+		opts.dragSteps = n
+	})
+}
+
+// AccelCurve maps the speed of successive steps to the fraction of the maximum
+// multiplier that is applied to them.
+// The speed is in range 0 <= speed <= 1, where 0 is for steps a full
+// acceleration window apart and 1 for steps that arrive at the same time.
+// The returned fraction must also be in range 0 <= f <= 1.
+type AccelCurve func(speed float64) float64
+
+// LinearAccel is an AccelCurve where the multiplier grows linearly with the
+// speed.
+func LinearAccel(speed float64) float64 {
+	return speed
+}
+
+// QuadraticAccel is an AccelCurve that keeps the multiplier low for moderate
+// speeds and only grows it quickly for fast turns.
+func QuadraticAccel(speed float64) float64 {
+	return speed * speed
+}
+
+// Acceleration multiplies the steps of mouse wheel and arrow key events that
+// follow the previous one in the same direction within the window.
+// The multiplier is between 1 and max, determined by the curve from how fast
+// the steps follow each other. Steps further apart than the window are never
+// multiplied, so slow turns still adjust by one step.
+// Acceleration is disabled by default.
+func Acceleration(curve AccelCurve, window time.Duration, max int) Option {
+	return option(func(opts *options) {
+		opts.accelCurve = curve
+		opts.accelWindow = window
+		opts.accelMax = max
+	})
+}
+
+// Range makes the encoder track a value between min and max that changes by
+// step on every step the encoder is turned. The value is clamped to the range,
+// the encoder is drawn in proportion to the position of the value within it
+// and the displayed text shows the value.
+// Encoders without a range move between 0 and 100 and wrap around.
+// The min must be less than max and step must be positive and no larger than
+// the range.
+func Range(min, max, step float64) Option {
+	return option(func(opts *options) {
+		opts.bounded = true
+		opts.lowerBound = min
+		opts.upperBound = max
+		opts.step = step
+	})
+}
+
+// Bipolar makes the encoder center-zero, e.g. for pan or detune. Zero is drawn
+// at 12 o'clock and the arc grows from it clockwise for positive and
+// counter-clockwise for negative values, or the other way round with
+// CounterClockwise(). The start angle is ignored. The displayed text shows the
+// sign and a double click turns the encoder back to zero.
+// Requires a Range that includes zero.
+func Bipolar() Option {
+	return option(func(opts *options) {
+		opts.bipolar = true
+	})
+}
+
 // DefaultLabelAlign is the default value for the LabelAlign option.
 const DefaultLabelAlign = align.HorizontalCenter
 
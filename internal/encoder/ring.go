@@ -0,0 +1,159 @@
+package encoder
+
+// ring.go contains the LED ring style of the encoder.
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/private/canvas/braille"
+)
+
+const (
+	// RingLEDs is the number of LEDs on the ring.
+	RingLEDs = 64
+	// RingLevels is the number of brightness levels of an LED, 0 is off.
+	RingLevels = 16
+)
+
+// SetRing sets the levels of the LEDs drawn with StyleRing. LED 0 is at the top
+// and the LEDs follow clockwise like on the monome arc. The levels must be
+// 0 <= l < RingLevels.
+func (d *Encoder) SetRing(levels [RingLEDs]int) error {
+	for i, l := range levels {
+		if l < 0 || l >= RingLevels {
+			return fmt.Errorf("invalid level %d of LED %d, must be 0 <= l < %d", l, i, RingLevels)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.ring = levels
+	return nil
+}
+
+// ringCommands apply the serialosc ring messages to the LED levels. The
+// arguments follow the ring number, levels out of range are clamped.
+var ringCommands = map[string]func(ring *[RingLEDs]int, args []int){
+	// /ring/set n x l sets LED x to level l.
+	"/ring/set": func(ring *[RingLEDs]int, args []int) {
+		if len(args) == 2 {
+			ring[ringIndex(args[0])] = ringLevel(args[1])
+		}
+	},
+	// /ring/all n l sets all the LEDs to level l.
+	"/ring/all": func(ring *[RingLEDs]int, args []int) {
+		if len(args) == 1 {
+			for i := range ring {
+				ring[i] = ringLevel(args[0])
+			}
+		}
+	},
+	// /ring/map n l[64] sets all the LEDs to the levels.
+	"/ring/map": func(ring *[RingLEDs]int, args []int) {
+		if len(args) == RingLEDs {
+			for i, l := range args {
+				ring[i] = ringLevel(l)
+			}
+		}
+	},
+	// /ring/range n x1 x2 l sets the LEDs from x1 clockwise to x2 to level l.
+	"/ring/range": func(ring *[RingLEDs]int, args []int) {
+		if len(args) == 3 {
+			from, to := ringIndex(args[0]), ringIndex(args[1])
+			for x := from; ; x = (x + 1) % RingLEDs {
+				ring[x] = ringLevel(args[2])
+				if x == to {
+					break
+				}
+			}
+		}
+	},
+}
+
+// ringIndex returns the LED index wrapped around the ring.
+func ringIndex(x int) int {
+	return (x%RingLEDs + RingLEDs) % RingLEDs
+}
+
+// ringLevel returns the level clamped to the valid levels.
+func ringLevel(l int) int {
+	if l < 0 {
+		return 0
+	}
+	if l >= RingLevels {
+		return RingLevels - 1
+	}
+	return l
+}
+
+// receiveRing applies the serialosc ring message to the LED levels if it is
+// addressed to the ring of the encoder.
+func (d *Encoder) receiveRing(msg *osc.Message, apply func(*[RingLEDs]int, []int)) {
+	var args []int
+	for _, a := range msg.Arguments {
+		v, ok := number(a)
+		if !ok {
+			return
+		}
+		args = append(args, int(math.Round(v)))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(args) == 0 || args[0] != d.opts.ringN {
+		return
+	}
+	apply(&d.ring, args[1:])
+}
+
+// drawRing draws the LEDs as segments of the circle between the center and the
+// outer edge. A cell shared by multiple LEDs gets the color of the brightest
+// one.
+func (d *Encoder) drawRing(bc *braille.Canvas, mid image.Point, r int) error {
+	inner := float64(d.centerRadius(r))
+	outer := float64(r) + 0.5
+	brightest := map[image.Point]int{}
+	ar := bc.Area()
+	for y := ar.Min.Y; y < ar.Max.Y; y++ {
+		for x := ar.Min.X; x < ar.Max.X; x++ {
+			p := image.Point{x, y}
+			dist := math.Hypot(float64(p.X-mid.X), float64(p.Y-mid.Y))
+			if dist <= inner || dist > outer {
+				continue
+			}
+			l := d.ring[ringLED(mid, p)]
+			if l == 0 {
+				continue
+			}
+			if err := bc.SetPixel(p); err != nil {
+				return fmt.Errorf("failed to draw the LED: %v", err)
+			}
+			cp := image.Point{p.X / braille.ColMult, p.Y / braille.RowMult}
+			if l > brightest[cp] {
+				brightest[cp] = l
+			}
+		}
+	}
+
+	for cp, l := range brightest {
+		if err := bc.SetCellOpts(cp, cell.FgColor(d.opts.ringColors[l])); err != nil {
+			return fmt.Errorf("failed to color the LED: %v", err)
+		}
+	}
+	return nil
+}
+
+// ringLED returns the index of the LED the pixel around the mid point belongs
+// to. LED 0 starts at the top and the LEDs follow clockwise.
+func ringLED(mid, p image.Point) int {
+	// Counter-clockwise from the positive X axis with Y growing up.
+	deg := math.Atan2(float64(mid.Y-p.Y), float64(p.X-mid.X)) * 180 / math.Pi
+	clockwise := math.Mod(90-deg+360, 360)
+	return int(clockwise/360*RingLEDs) % RingLEDs
+}
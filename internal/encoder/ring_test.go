@@ -0,0 +1,254 @@
+package encoder
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/faketerm"
+	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+func TestRingLED(t *testing.T) {
+	mid := image.Point{6, 13}
+	tests := []struct {
+		desc string
+		p    image.Point
+		want int
+	}{
+		{desc: "top", p: image.Point{6, 7}, want: 0},
+		{desc: "right", p: image.Point{12, 13}, want: 16},
+		{desc: "bottom", p: image.Point{6, 19}, want: 32},
+		{desc: "left", p: image.Point{0, 13}, want: 48},
+		{desc: "left of the top", p: image.Point{5, 7}, want: 62},
+		{desc: "right of the top", p: image.Point{7, 7}, want: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := ringLED(mid, tc.p); got != tc.want {
+				t.Errorf("ringLED(%v, %v) => %d, want %d", mid, tc.p, got, tc.want)
+			}
+		})
+	}
+}
+
+// levels returns ring levels with the LEDs set to the level.
+func levels(level int, leds ...int) [RingLEDs]int {
+	var ring [RingLEDs]int
+	for _, x := range leds {
+		ring[x] = level
+	}
+	return ring
+}
+
+func TestSetRing(t *testing.T) {
+	tests := []struct {
+		desc    string
+		levels  [RingLEDs]int
+		wantErr bool
+	}{
+		{
+			desc:   "sets the levels",
+			levels: levels(15, 0, 1, 63),
+		},
+		{
+			desc:    "fails on negative level",
+			levels:  levels(-1, 3),
+			wantErr: true,
+		},
+		{
+			desc:    "fails on too large level",
+			levels:  levels(RingLevels, 3),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(DrawStyle(StyleRing))
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			err = d.SetRing(tc.levels)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SetRing => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := pretty.Compare(tc.levels, d.ring); diff != "" {
+				t.Errorf("unexpected levels (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRingOSC(t *testing.T) {
+	mapped := []interface{}{int32(1)}
+	var want [RingLEDs]int
+	for i := 0; i < RingLEDs; i++ {
+		mapped = append(mapped, int32(i%RingLevels))
+		want[i] = i % RingLevels
+	}
+
+	tests := []struct {
+		desc string
+		msgs []*osc.Message
+		want [RingLEDs]int
+	}{
+		{
+			desc: "map sets all the levels",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/map", mapped...),
+			},
+			want: want,
+		},
+		{
+			desc: "set sets one LED",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/set", int32(1), int32(3), int32(9)),
+			},
+			want: levels(9, 3),
+		},
+		{
+			desc: "all sets all the LEDs",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/all", int32(1), int32(4)),
+			},
+			want: func() [RingLEDs]int {
+				var ring [RingLEDs]int
+				for i := range ring {
+					ring[i] = 4
+				}
+				return ring
+			}(),
+		},
+		{
+			desc: "range wraps around the top",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/range", int32(1), int32(62), int32(1), int32(15)),
+			},
+			want: levels(15, 62, 63, 0, 1),
+		},
+		{
+			desc: "clamps the levels",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/set", int32(1), int32(0), int32(20)),
+				osc.NewMessage("/monome/ring/set", int32(1), int32(1), int32(-3)),
+			},
+			want: levels(15, 0),
+		},
+		{
+			desc: "ignores other rings, prefixes and malformed messages",
+			msgs: []*osc.Message{
+				osc.NewMessage("/monome/ring/all", int32(0), int32(4)),
+				osc.NewMessage("/other/ring/all", int32(1), int32(4)),
+				osc.NewMessage("/monome/ring/set", int32(1), int32(4)),
+				osc.NewMessage("/monome/ring/set", int32(1), "x", int32(4)),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			l, err := transport.Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("transport.Listen => unexpected error: %v", err)
+			}
+			defer l.Close()
+
+			d, err := New(DrawStyle(StyleRing), RingOSC(l, "/monome", 1))
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			for _, msg := range tc.msgs {
+				l.Dispatch(msg)
+			}
+			if diff := pretty.Compare(tc.want, d.ring); diff != "" {
+				t.Errorf("unexpected levels (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDrawRing(t *testing.T) {
+	tests := []struct {
+		desc   string
+		levels [RingLEDs]int
+		// inQuadrant asserts whether the cell is where the lit LEDs are.
+		inQuadrant func(p, mid image.Point) bool
+		wantColor  cell.Color
+	}{
+		{
+			desc:      "draws nothing when all LEDs are off",
+			wantColor: cell.ColorDefault,
+		},
+		{
+			desc:   "draws the top right quarter",
+			levels: levels(15, 0, 4, 8, 12, 15),
+			inQuadrant: func(p, mid image.Point) bool {
+				return p.X >= mid.X && p.Y <= mid.Y
+			},
+			wantColor: cell.ColorNumber(255),
+		},
+		{
+			desc:   "draws the bottom left quarter dimmed",
+			levels: levels(1, 32, 36, 40, 44, 47),
+			inQuadrant: func(p, mid image.Point) bool {
+				return p.X <= mid.X && p.Y >= mid.Y
+			},
+			wantColor: cell.ColorNumber(236),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(DrawStyle(StyleRing), HideTextProgress())
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.SetRing(tc.levels); err != nil {
+				t.Fatalf("SetRing => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(image.Rect(0, 0, 7, 7))
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			mid := image.Point{3, 3}
+			lit := 0
+			buf := ft.BackBuffer()
+			for x, col := range buf {
+				for y, cl := range col {
+					if cl.Rune == 0 || cl.Rune == ' ' || cl.Rune == '⠀' {
+						continue
+					}
+					lit++
+					p := image.Point{x, y}
+					if tc.inQuadrant == nil || !tc.inQuadrant(p, mid) {
+						t.Errorf("cell %v is lit, want only the cells of the lit LEDs", p)
+					}
+					if got := cl.Opts.FgColor; got != tc.wantColor {
+						t.Errorf("cell %v has color %v, want %v", p, got, tc.wantColor)
+					}
+				}
+			}
+			if tc.inQuadrant != nil && lit == 0 {
+				t.Errorf("no cells are lit, want the cells of the lit LEDs")
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package encoder
+
+// style.go contains the needle, bar and numeric styles of the encoder and the
+// compact rendering used when the area is too small for the style.
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/mum4k/termdash/align"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/private/alignfor"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/canvas/braille"
+	"github.com/mum4k/termdash/private/draw"
+	"github.com/mum4k/termdash/private/numbers/trig"
+	"github.com/mum4k/termdash/private/runewidth"
+)
+
+// SetStyle switches how the encoder is drawn, e.g. to a bar or a numeric
+// readout when the terminal gets small. The value, the options and the input
+// handling stay the same.
+func (d *Encoder) SetStyle(s Style) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	prev := d.opts.style
+	d.opts.style = s
+	if err := d.opts.validate(); err != nil {
+		d.opts.style = prev
+		return err
+	}
+	return nil
+}
+
+// drawNeedle draws the outline of the full sweep and a needle from the middle
+// to the edge that points at the value.
+// The mid point and radius are in pixels on the braille canvas.
+func (d *Encoder) drawNeedle(bc *braille.Canvas, mid image.Point, r int) error {
+	o := d.opts
+	circleOpts := []draw.BrailleCircleOption{
+		draw.BrailleCircleCellOpts(d.ringCellOpts()...),
+	}
+	if start, end := startEndAngles(d.total, d.total, d.startAngle(), o.sweep, o.direction); start != 0 || end != 360 {
+		circleOpts = append(circleOpts, draw.BrailleCircleArcOnly(start, end))
+	}
+	if err := draw.BrailleCircle(bc, mid, r, circleOpts...); err != nil {
+		return fmt.Errorf("failed to draw the track: %v", err)
+	}
+
+	a := valueAngle(d.current, d.total, d.startAngle(), o.sweep, o.direction)
+	tip := trig.CirclePointAtAngle(a, mid, r)
+	if err := draw.BrailleLine(bc, mid, tip, draw.BrailleLineCellOpts(d.ringCellOpts()...)); err != nil {
+		return fmt.Errorf("failed to draw the needle: %v", err)
+	}
+	return nil
+}
+
+// drawBar draws the value as a meter filling the area from the left or from
+// the bottom and the text over the middle of the meter if it fits.
+func (d *Encoder) drawBar(cvs *canvas.Canvas, ar image.Rectangle) error {
+	if err := d.drawMeter(cvs, ar, d.opts.style == StyleBarVertical); err != nil {
+		return err
+	}
+
+	if d.opts.hideTextProgress {
+		return nil
+	}
+	t := d.progressText()
+	if runewidth.StringWidth(t) > ar.Dx() {
+		return nil
+	}
+	return d.drawTextIn(cvs, ar, t, d.opts.textCellOpts)
+}
+
+// drawMeter fills the area with braille pixels from the left or from the
+// bottom up to the value, or from zero for bipolar encoders. The remote value
+// that wasn't taken over is marked by toggling the pixels across the meter.
+func (d *Encoder) drawMeter(cvs *canvas.Canvas, ar image.Rectangle, vertical bool) error {
+	bc, err := braille.New(ar)
+	if err != nil {
+		return fmt.Errorf("braille.New => %v", err)
+	}
+
+	length, width := bc.Area().Dx(), bc.Area().Dy()
+	if vertical {
+		length, width = width, length
+	}
+	// pixel returns the pixel along the meter for the position in steps.
+	pixel := func(pos int) int {
+		return int(math.Round(float64(pos) / float64(d.total) * float64(length)))
+	}
+	// point returns the point of the pixel i along and j across the meter.
+	point := func(i, j int) image.Point {
+		if vertical {
+			return image.Point{j, length - 1 - i}
+		}
+		return image.Point{i, j}
+	}
+
+	from, to := 0, d.current
+	if d.opts.bipolar {
+		from = d.zero()
+	}
+	if to < from {
+		from, to = to, from
+	}
+	for i := pixel(from); i < pixel(to); i++ {
+		for j := 0; j < width; j++ {
+			if err := bc.SetPixel(point(i, j), d.ringCellOpts()...); err != nil {
+				return fmt.Errorf("failed to draw the meter: %v", err)
+			}
+		}
+	}
+
+	if d.hasRemote && !d.pickedUp {
+		i := pixel(d.remotePosition())
+		if i == length {
+			i--
+		}
+		for j := 0; j < width; j++ {
+			if err := bc.TogglePixel(point(i, j), d.opts.ghostCellOpts...); err != nil {
+				return fmt.Errorf("failed to draw the remote value: %v", err)
+			}
+		}
+	}
+	return bc.CopyTo(cvs)
+}
+
+// drawNumeric draws the displayed text in the middle of the area. The text
+// gets the fine cell options while the fine adjustment is on and is shortened
+// if it doesn't fit.
+func (d *Encoder) drawNumeric(cvs *canvas.Canvas, ar image.Rectangle) error {
+	cOpts := d.opts.textCellOpts
+	if d.fine {
+		cOpts = append(append([]cell.Option{}, cOpts...), d.opts.fineCellOpts...)
+	}
+	return d.drawTextIn(cvs, ar, d.progressText(), cOpts)
+}
+
+// drawTextIn draws the text centered in the area.
+func (d *Encoder) drawTextIn(cvs *canvas.Canvas, ar image.Rectangle, t string, cOpts []cell.Option) error {
+	start, err := alignfor.Text(ar, t, align.HorizontalCenter, align.VerticalMiddle)
+	if err != nil {
+		return fmt.Errorf("alignfor.Text => %v", err)
+	}
+	if err := draw.Text(cvs, t, start,
+		draw.TextOverrunMode(draw.OverrunModeThreeDot),
+		draw.TextMaxX(ar.Max.X),
+		draw.TextCellOpts(cOpts...),
+	); err != nil {
+		return fmt.Errorf("draw.Text => %v", err)
+	}
+	return nil
+}
+
+// compactMeterMin is the smallest width in cells of the meter of the compact
+// rendering, it is left out on narrower lines.
+const compactMeterMin = 3
+
+// drawCompact draws the encoder on the middle line of the canvas, the label
+// followed by the text and a meter in the rest of the line. Used when the
+// canvas is too small for the style, the parts that don't fit are shortened
+// or left out. The text is drawn even with HideTextProgress(), the line is
+// the only place that shows the value, so the label only gets the cells the
+// text leaves.
+func (d *Encoder) drawCompact(cvs *canvas.Canvas) error {
+	ar := cvs.Area()
+	d.encoderAr = ar
+	y := ar.Min.Y + (ar.Dy()-1)/2
+
+	text := d.progressText()
+	textWidth := runewidth.StringWidth(text)
+	x := ar.Min.X
+	if label := d.opts.label; label != "" {
+		labelMaxX := ar.Max.X
+		if text != "" {
+			labelMaxX -= textWidth + 1
+		}
+		if labelMaxX > x {
+			if err := draw.Text(cvs, label, image.Point{x, y},
+				draw.TextOverrunMode(draw.OverrunModeThreeDot),
+				draw.TextMaxX(labelMaxX),
+				draw.TextCellOpts(d.opts.labelCellOpts...),
+			); err != nil {
+				return fmt.Errorf("draw.Text => %v", err)
+			}
+			x += runewidth.StringWidth(label) + 1
+			if x > labelMaxX+1 {
+				x = labelMaxX + 1
+			}
+		}
+	}
+	if text != "" && x < ar.Max.X {
+		if err := draw.Text(cvs, text, image.Point{x, y},
+			draw.TextOverrunMode(draw.OverrunModeThreeDot),
+			draw.TextMaxX(ar.Max.X),
+			draw.TextCellOpts(d.opts.textCellOpts...),
+		); err != nil {
+			return fmt.Errorf("draw.Text => %v", err)
+		}
+		x += textWidth + 1
+	}
+
+	if d.opts.style == StyleNumeric || ar.Max.X-x < compactMeterMin {
+		return nil
+	}
+	meterAr := image.Rect(x, y, ar.Max.X, y+1)
+	if d.opts.ascii || d.opts.style == StyleRing {
+		return d.drawASCIIMeter(cvs, meterAr)
+	}
+	return d.drawMeter(cvs, meterAr, false)
+}
@@ -0,0 +1,326 @@
+package encoder
+
+import (
+	"image"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/faketerm"
+	"github.com/mum4k/termdash/widgetapi"
+)
+
+// rows returns the runes of the terminal row by row, empty cells as spaces.
+func rows(ft *faketerm.Terminal) []string {
+	buf := ft.BackBuffer()
+	size := ft.Size()
+	var got []string
+	for y := 0; y < size.Y; y++ {
+		var row []rune
+		for x := 0; x < size.X; x++ {
+			r := buf[x][y].Rune
+			if r == 0 {
+				r = ' '
+			}
+			row = append(row, r)
+		}
+		got = append(got, string(row))
+	}
+	return got
+}
+
+func TestDrawStyles(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		canvas  image.Rectangle
+		percent int
+		want    []string
+	}{
+		{
+			desc: "horizontal bar fills from the left",
+			opts: []Option{
+				DrawStyle(StyleBarHorizontal),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 4, 1),
+			percent: 50,
+			want:    []string{"⣿⣿  "},
+		},
+		{
+			desc: "vertical bar fills from the bottom",
+			opts: []Option{
+				DrawStyle(StyleBarVertical),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 1, 4),
+			percent: 25,
+			want:    []string{" ", " ", " ", "⣿"},
+		},
+		{
+			desc: "bipolar bar fills from zero",
+			opts: []Option{
+				Range(-1, 1, 0.5),
+				Bipolar(),
+				DrawStyle(StyleBarHorizontal),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 4, 1),
+			percent: 25,
+			want:    []string{" ⣿  "},
+		},
+		{
+			desc: "bar draws the text over the middle",
+			opts: []Option{
+				DrawStyle(StyleBarHorizontal),
+			},
+			canvas:  image.Rect(0, 0, 6, 1),
+			percent: 50,
+			want:    []string{"⣿50%  "},
+		},
+		{
+			desc: "bar doesn't draw text that doesn't fit",
+			opts: []Option{
+				DrawStyle(StyleBarHorizontal),
+			},
+			canvas:  image.Rect(0, 0, 3, 1),
+			percent: 100,
+			want:    []string{"⣿⣿⣿"},
+		},
+		{
+			desc: "bar draws the label below",
+			opts: []Option{
+				DrawStyle(StyleBarHorizontal),
+				HideTextProgress(),
+				Label("E1"),
+			},
+			canvas:  image.Rect(0, 0, 4, 3),
+			percent: 100,
+			want:    []string{"⣿⣿⣿⣿", "    ", " E1 "},
+		},
+		{
+			desc: "numeric draws the text in the middle",
+			opts: []Option{
+				DrawStyle(StyleNumeric),
+			},
+			canvas:  image.Rect(0, 0, 5, 3),
+			percent: 50,
+			want:    []string{"     ", " 50% ", "     "},
+		},
+		{
+			desc: "numeric draws the text even when hidden",
+			opts: []Option{
+				DrawStyle(StyleNumeric),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 3, 1),
+			percent: 50,
+			want:    []string{"50%"},
+		},
+		{
+			desc: "numeric shortens the text that doesn't fit",
+			opts: []Option{
+				DrawStyle(StyleNumeric),
+			},
+			canvas:  image.Rect(0, 0, 2, 1),
+			percent: 50,
+			want:    []string{"5…"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(tc.canvas)
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			if diff := pretty.Compare(tc.want, rows(ft)); diff != "" {
+				t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetStyle(t *testing.T) {
+	tests := []struct {
+		desc      string
+		style     Style
+		want      Style
+		wantRatio image.Point
+		wantErr   bool
+	}{
+		{
+			desc:      "switches to the needle and keeps the circle ratio",
+			style:     StyleNeedle,
+			want:      StyleNeedle,
+			wantRatio: image.Point{4, 2},
+		},
+		{
+			desc:  "switches to the numeric readout without a ratio",
+			style: StyleNumeric,
+			want:  StyleNumeric,
+		},
+		{
+			desc:  "switches to the horizontal bar without a ratio",
+			style: StyleBarHorizontal,
+			want:  StyleBarHorizontal,
+		},
+		{
+			desc:      "fails on unknown style and keeps the previous one",
+			style:     StyleNumeric + 1,
+			want:      StyleKnob,
+			wantRatio: image.Point{4, 2},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New()
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			err = d.SetStyle(tc.style)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SetStyle => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+			if got := d.opts.style; got != tc.want {
+				t.Errorf("SetStyle => style %v, want %v", got, tc.want)
+			}
+			if got := d.Options().Ratio; got != tc.wantRatio {
+				t.Errorf("Options => ratio %v, want %v", got, tc.wantRatio)
+			}
+		})
+	}
+}
+
+func TestDrawCompact(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		canvas  image.Rectangle
+		percent int
+		want    []string
+	}{
+		{
+			desc: "draws the label, the text and a meter when the label takes all the rows",
+			opts: []Option{
+				Label("E1"),
+			},
+			canvas:  image.Rect(0, 0, 12, 2),
+			percent: 50,
+			want:    []string{"E1 50% ⣿⣿⡇  ", "            "},
+		},
+		{
+			desc: "draws in the middle row of a circle that doesn't fit",
+			opts: []Option{
+				Label("E1"),
+			},
+			canvas:  image.Rect(0, 0, 12, 4),
+			percent: 100,
+			want:    []string{"            ", "E1 100% ⣿⣿⣿⣿", "            ", "            "},
+		},
+		{
+			desc: "leaves out the meter when it doesn't fit",
+			opts: []Option{
+				Label("E1"),
+			},
+			canvas:  image.Rect(0, 0, 8, 2),
+			percent: 50,
+			want:    []string{"E1 50%  ", "        "},
+		},
+		{
+			desc: "shortens the label to keep the text",
+			opts: []Option{
+				Label("Cutoff"),
+			},
+			canvas:  image.Rect(0, 0, 8, 1),
+			percent: 50,
+			want:    []string{"Cut… 50%"},
+		},
+		{
+			desc: "leaves out the label when only the text fits",
+			opts: []Option{
+				Label("Cutoff"),
+			},
+			canvas:  image.Rect(0, 0, 4, 1),
+			percent: 50,
+			want:    []string{"50% "},
+		},
+		{
+			desc: "shortens the text that doesn't fit",
+			opts: []Option{
+				Label("Cutoff"),
+			},
+			canvas:  image.Rect(0, 0, 3, 1),
+			percent: 100,
+			want:    []string{"10…"},
+		},
+		{
+			desc: "draws the text and the ASCII meter when the text is hidden",
+			opts: []Option{
+				ASCII(),
+				Label("E1"),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 14, 2),
+			percent: 50,
+			want:    []string{"E1 50% [==|  ]", "              "},
+		},
+		{
+			desc: "numeric draws the label and the text",
+			opts: []Option{
+				DrawStyle(StyleNumeric),
+				Label("E1"),
+				HideTextProgress(),
+			},
+			canvas:  image.Rect(0, 0, 10, 1),
+			percent: 50,
+			want:    []string{"E1 50%    "},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			d, err := New(tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if err := d.Percent(tc.percent); err != nil {
+				t.Fatalf("Percent => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(tc.canvas)
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := d.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			if diff := pretty.Compare(tc.want, rows(ft)); diff != "" {
+				t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
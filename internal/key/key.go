@@ -0,0 +1,388 @@
+// Package key is a widget that acts like a hardware key, e.g. one of the keys
+// of a norns. It is pressed while the mouse button is held down on it or with
+// bound keyboard keys and sends the press and the release to an OSC route.
+package key
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/mum4k/termdash/align"
+	"github.com/mum4k/termdash/keyboard"
+	"github.com/mum4k/termdash/mouse"
+	"github.com/mum4k/termdash/private/alignfor"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/draw"
+	"github.com/mum4k/termdash/terminal/terminalapi"
+	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+// Key displays a key that is pressed with the left mouse button or the bound
+// keyboard keys.
+//
+// Implements widgetapi.Widget. This object is thread-safe.
+type Key struct {
+	// pressed is true while the key is down.
+	pressed bool
+	// buttonDown is true while the left mouse button is held down, wherever
+	// it was pressed.
+	buttonDown bool
+	// held is true while the left mouse button that was pressed on the key is
+	// held down.
+	held bool
+
+	// holdGen counts the events of bound keyboard keys, a hold timeout only
+	// releases the key if no event followed the one that started it.
+	holdGen int
+	// stopHold stops the pending hold timeout, if any.
+	stopHold func() bool
+	// after calls the function after the duration in its own goroutine and
+	// returns a function that stops it, replaceable in tests.
+	after func(time.Duration, func()) func() bool
+	// lastKey is when a bound keyboard key was last pressed or repeated, zero
+	// after Release().
+	lastKey time.Time
+	// now returns the current time, replaceable in tests.
+	now func() time.Time
+
+	// mu protects the Key.
+	mu sync.Mutex
+
+	// opts are the provided options.
+	opts *options
+
+	// changes are the states the key changed to while handling an input
+	// event, delivered to the subscribers once k.mu is released.
+	changes []bool
+	// calls are the OnButton functions to call once k.mu is released.
+	calls []func()
+
+	// target sends the OSC route to the receiver set by OscTarget(), opened
+	// when first needed.
+	target *transport.Target
+	// closed is true once Close() was called.
+	closed bool
+}
+
+// New returns a new Key.
+func New(opts ...Option) (*Key, error) {
+	opt := newOptions()
+	for _, o := range opts {
+		o.set(opt)
+	}
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	return &Key{
+		opts: opt,
+		after: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+		now: time.Now,
+	}, nil
+}
+
+// Press presses the key as if the mouse button was pressed on it, e.g. for a
+// source of key events other than the terminal.
+// Returns the first error returned by an OnChange function.
+func (k *Key) Press() error {
+	return k.input(func() { k.set(true) })
+}
+
+// Release releases the key, e.g. when a source of key events other than the
+// terminal reports that a bound keyboard key was released. Cancels the pending
+// hold timeout, the next event of a bound keyboard key is a new press.
+// Returns the first error returned by an OnChange function.
+func (k *Key) Release() error {
+	return k.input(func() {
+		k.cancelHold()
+		k.lastKey = time.Time{}
+		k.set(false)
+	})
+}
+
+// IsPressed asserts whether the key is down.
+func (k *Key) IsPressed() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.pressed
+}
+
+// set changes the state of the key and records the change for the
+// subscribers.
+// The caller must hold k.mu.
+func (k *Key) set(pressed bool) {
+	if pressed == k.pressed {
+		return
+	}
+	k.pressed = pressed
+	k.changes = append(k.changes, pressed)
+}
+
+// Draw draws the Key widget onto the canvas.
+// Implements widgetapi.Widget.Draw.
+func (k *Key) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	cOpts := k.opts.cellOpts
+	if k.pressed {
+		cOpts = k.opts.pressedCellOpts
+	}
+	ar := cvs.Area()
+	if err := draw.Rectangle(cvs, ar, draw.RectChar(' '), draw.RectCellOpts(cOpts...)); err != nil {
+		return fmt.Errorf("failed to draw the key: %v", err)
+	}
+
+	if k.opts.label == "" {
+		return nil
+	}
+	start, err := alignfor.Text(ar, k.opts.label, align.HorizontalCenter, align.VerticalMiddle)
+	if err != nil {
+		return fmt.Errorf("alignfor.Text => %v", err)
+	}
+	return draw.Text(cvs, k.opts.label, start,
+		draw.TextOverrunMode(draw.OverrunModeThreeDot),
+		draw.TextMaxX(ar.Max.X),
+		draw.TextCellOpts(cOpts...),
+	)
+}
+
+// Keyboard presses the key when one of the keyboard keys provided to Bind() is
+// pressed, releasing it after the hold timeout or with Latch() on the next
+// press. Events within the hold timeout of the previous one are repeats of the
+// held keyboard key, they don't release a latched key.
+// Implements widgetapi.Widget.Keyboard.
+func (k *Key) Keyboard(ev *terminalapi.Keyboard, _ *widgetapi.EventMeta) error {
+	return k.input(func() { k.keyboard(ev) })
+}
+
+// keyboard handles the keyboard event.
+// TODO: Release the key on the release events of the kitty keyboard protocol
+// instead of the hold timeout. Not implemented: tcell v2.4, which termdash
+// reads the terminal with, only reports presses, and turning the protocol on
+// would make it deliver the escape sequences of releases as stray keys.
+// The caller must hold k.mu.
+func (k *Key) keyboard(ev *terminalapi.Keyboard) {
+	if !k.bound(ev.Key) {
+		return
+	}
+	now := k.now()
+	repeat := !k.lastKey.IsZero() && now.Sub(k.lastKey) < k.opts.holdTimeout
+	k.lastKey = now
+	if k.opts.toggle || k.opts.latch {
+		// The terminal repeats the event while the keyboard key is held down,
+		// only a new press toggles.
+		if !repeat {
+			k.set(!k.pressed)
+		}
+		return
+	}
+
+	// Every repeat of the held keyboard key extends the hold.
+	k.cancelHold()
+	gen := k.holdGen
+	k.stopHold = k.after(k.opts.holdTimeout, func() {
+		err := k.input(func() {
+			if gen == k.holdGen {
+				k.set(false)
+			}
+		})
+		if err != nil {
+			log.Printf("error releasing key: %v", err)
+		}
+	})
+	k.set(true)
+}
+
+// bound asserts whether the keyboard key was provided to Bind().
+// The caller must hold k.mu.
+func (k *Key) bound(key keyboard.Key) bool {
+	for _, b := range k.opts.bindings {
+		if b == key {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelHold stops the pending hold timeout, a timeout that already fired
+// doesn't release the key.
+// The caller must hold k.mu.
+func (k *Key) cancelHold() {
+	k.holdGen++
+	if k.stopHold != nil {
+		k.stopHold()
+		k.stopHold = nil
+	}
+}
+
+// Mouse presses the key when the left button is pressed on it and releases it
+// when the button is released, even outside of the key. With Toggle() every
+// press alternates the state instead. Other buttons call the functions
+// provided to OnButton().
+// Implements widgetapi.Widget.Mouse.
+func (k *Key) Mouse(m *terminalapi.Mouse, _ *widgetapi.EventMeta) error {
+	return k.input(func() { k.mouse(m) })
+}
+
+// mouse handles the mouse event.
+// The caller must hold k.mu.
+func (k *Key) mouse(m *terminalapi.Mouse) {
+	switch m.Button {
+	case mouse.ButtonLeft, mouse.ButtonRight, mouse.ButtonMiddle:
+		// The terminal repeats the event while the button is held down and
+		// moved, only the first one presses.
+		if k.buttonDown {
+			return
+		}
+		k.buttonDown = true
+		// Events that fall outside of the canvas are only received so that a
+		// press released outside of the key ends.
+		if m.Position == (image.Point{-1, -1}) {
+			return
+		}
+		if m.Button != mouse.ButtonLeft {
+			for _, b := range k.opts.onButton {
+				if b.button == m.Button {
+					k.calls = append(k.calls, b.fn)
+				}
+			}
+			return
+		}
+		k.held = true
+		k.cancelHold() // The mouse holds the key now.
+		if k.opts.toggle {
+			k.set(!k.pressed)
+			return
+		}
+		k.set(true)
+
+	case mouse.ButtonRelease:
+		k.buttonDown = false
+		if !k.held {
+			return
+		}
+		k.held = false
+		if !k.opts.toggle {
+			k.set(false)
+		}
+	}
+}
+
+// input handles an input event under k.mu and notifies the subscribers about
+// the changes it made once the lock is released, so that subscribers and
+// OnButton functions can call back into the key.
+// Returns the first error returned by a subscriber.
+func (k *Key) input(handle func()) error {
+	k.mu.Lock()
+	handle()
+	changes, calls := k.changes, k.calls
+	k.changes, k.calls = nil, nil
+	subs := k.subscribers()
+	k.mu.Unlock()
+
+	for _, fn := range calls {
+		fn()
+	}
+	for _, pressed := range changes {
+		for _, sub := range subs {
+			if err := sub(pressed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subscribers returns the functions notified about changes of the key, the
+// OSC route first.
+// The caller must hold k.mu.
+func (k *Key) subscribers() []ChangeFunc {
+	var subs []ChangeFunc
+	if o := k.opts; o.oscRoute != "" {
+		if t := k.routeTransport(); t != nil {
+			subs = append(subs, oscSender(t, o.oscRoute))
+		}
+	}
+	return append(subs, k.opts.onChange...)
+}
+
+// routeTransport returns the transport the OSC route is sent with, the one
+// provided to Transport() or the target that sends to the receiver over UDP,
+// shared with the other widgets sending to it. Returns nil once the key was
+// closed.
+// The caller must hold k.mu.
+func (k *Key) routeTransport() transport.Transport {
+	o := k.opts
+	if o.transport != nil {
+		return o.transport
+	}
+	if k.closed || !o.hasTarget {
+		return nil
+	}
+	if k.target == nil {
+		// The port was validated, NewTarget can't fail. Presses and releases
+		// are never merged.
+		k.target, _ = transport.NewTarget(o.oscAddr, o.oscPort)
+	}
+	return k.target
+}
+
+// Close closes the target set by OscTarget(), the key doesn't send to it
+// afterwards. Once all the widgets sending to the receiver are closed, the
+// OSC messages still queued are sent and the connection is closed. Release
+// the key first so that the receiver doesn't keep it held. A transport
+// provided with Transport() isn't closed.
+func (k *Key) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.cancelHold()
+	k.closed = true
+	if k.target == nil {
+		return nil
+	}
+	t := k.target
+	k.target = nil
+	return t.Close()
+}
+
+// oscSender returns a subscriber that sends 1 for a press and 0 for a release
+// to the OSC route with the transport.
+// Errors sending are logged and not returned, a receiver that went away
+// shouldn't stop the dashboard.
+func oscSender(t transport.Transport, route string) ChangeFunc {
+	return func(pressed bool) error {
+		state := int32(0)
+		if pressed {
+			state = 1
+		}
+		if err := t.Send(osc.NewMessage(route, state)); err != nil {
+			log.Printf("error sending osc message: %v", err)
+		}
+		return nil
+	}
+}
+
+// Options implements widgetapi.Widget.Options.
+// Keys with bound keyboard keys receive the keyboard events of all containers.
+func (k *Key) Options() widgetapi.Options {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	ks := widgetapi.KeyScopeNone
+	if len(k.opts.bindings) > 0 {
+		ks = widgetapi.KeyScopeGlobal
+	}
+	return widgetapi.Options{
+		WantKeyboard: ks,
+		WantMouse:    widgetapi.MouseScopeGlobal,
+	}
+}
@@ -0,0 +1,579 @@
+package key
+
+import (
+	"errors"
+	"image"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/keyboard"
+	"github.com/mum4k/termdash/mouse"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/faketerm"
+	"github.com/mum4k/termdash/terminal/terminalapi"
+	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			desc: "succeeds with the default options",
+		},
+		{
+			desc: "fails on zero hold timeout",
+			opts: []Option{
+				HoldTimeout(0),
+			},
+			wantErr: true,
+		},
+		{
+			desc: "fails on OnButton with the left button",
+			opts: []Option{
+				OnButton(mouse.ButtonLeft, func() {}),
+			},
+			wantErr: true,
+		},
+		{
+			desc: "fails on nil OnButton function",
+			opts: []Option{
+				OnButton(mouse.ButtonRight, nil),
+			},
+			wantErr: true,
+		},
+		{
+			desc: "fails on nil OnChange function",
+			opts: []Option{
+				OnChange(nil),
+			},
+			wantErr: true,
+		},
+		{
+			desc: "fails on an OSC route without a target or a transport",
+			opts: []Option{
+				OscRoute("/remote/key/1"),
+			},
+			wantErr: true,
+		},
+		{
+			desc: "fails on an invalid OSC target port",
+			opts: []Option{
+				OscRoute("/remote/key/1"),
+				OscTarget("127.0.0.1", 0),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := New(tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("New => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// states returns the int32 arguments of the messages sent to the route.
+func states(t *testing.T, msgs []*osc.Message, route string) []int32 {
+	t.Helper()
+	var got []int32
+	for _, m := range msgs {
+		if m.Address != route {
+			t.Errorf("message sent to %q, want %q", m.Address, route)
+		}
+		for _, a := range m.Arguments {
+			got = append(got, a.(int32))
+		}
+	}
+	return got
+}
+
+func TestMouse(t *testing.T) {
+	var (
+		on      = &terminalapi.Mouse{Position: image.Point{1, 1}, Button: mouse.ButtonLeft}
+		outside = &terminalapi.Mouse{Position: image.Point{-1, -1}, Button: mouse.ButtonLeft}
+		release = &terminalapi.Mouse{Position: image.Point{-1, -1}, Button: mouse.ButtonRelease}
+	)
+
+	tests := []struct {
+		desc        string
+		opts        []Option
+		events      []*terminalapi.Mouse
+		want        []int32
+		wantPressed bool
+	}{
+		{
+			desc:        "sends 1 on press",
+			events:      []*terminalapi.Mouse{on},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+		{
+			desc:   "sends 0 on release, even outside of the key",
+			events: []*terminalapi.Mouse{on, release},
+			want:   []int32{1, 0},
+		},
+		{
+			desc:        "ignores the repeated events of a held button",
+			events:      []*terminalapi.Mouse{on, on, on},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+		{
+			desc:   "ignores a button pressed elsewhere and moved onto the key",
+			events: []*terminalapi.Mouse{outside, on, release},
+		},
+		{
+			desc:   "ignores other buttons",
+			events: []*terminalapi.Mouse{{Position: image.Point{1, 1}, Button: mouse.ButtonRight}, release},
+		},
+		{
+			desc: "toggles on every press",
+			opts: []Option{
+				Toggle(),
+			},
+			events:      []*terminalapi.Mouse{on, release, on, release, on, release},
+			want:        []int32{1, 0, 1},
+			wantPressed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/key/1")}, tc.opts...)
+			k, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			for _, m := range tc.events {
+				if err := k.Mouse(m, &widgetapi.EventMeta{}); err != nil {
+					t.Fatalf("Mouse(%v) => unexpected error: %v", m, err)
+				}
+			}
+
+			got := states(t, rec.Messages(), "/remote/key/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Mouse => unexpected states (-want, +got):\n%s", diff)
+			}
+			if got := k.IsPressed(); got != tc.wantPressed {
+				t.Errorf("IsPressed => %v, want %v", got, tc.wantPressed)
+			}
+		})
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	var got []bool
+	k, err := New(
+		OnChange(func(pressed bool) error {
+			got = append(got, pressed)
+			return nil
+		}),
+		OnChange(func(pressed bool) error {
+			if !pressed {
+				return errors.New("subscriber failed")
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+
+	if err := k.Mouse(&terminalapi.Mouse{Button: mouse.ButtonLeft}, &widgetapi.EventMeta{}); err != nil {
+		t.Fatalf("Mouse => unexpected error: %v", err)
+	}
+	if err := k.Mouse(&terminalapi.Mouse{Button: mouse.ButtonRelease}, &widgetapi.EventMeta{}); err == nil {
+		t.Errorf("Mouse => got nil error, want the error of the subscriber")
+	}
+	if diff := pretty.Compare([]bool{true, false}, got); diff != "" {
+		t.Errorf("unexpected calls (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOnButton(t *testing.T) {
+	var calls []string
+	call := func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+	k, err := New(
+		OnButton(mouse.ButtonRight, call("right")),
+		OnButton(mouse.ButtonMiddle, call("middle")),
+	)
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+
+	events := []*terminalapi.Mouse{
+		{Position: image.Point{1, 1}, Button: mouse.ButtonRight},
+		{Position: image.Point{1, 1}, Button: mouse.ButtonRight},
+		{Button: mouse.ButtonRelease},
+		{Position: image.Point{-1, -1}, Button: mouse.ButtonMiddle},
+		{Button: mouse.ButtonRelease},
+		{Position: image.Point{1, 1}, Button: mouse.ButtonMiddle},
+	}
+	for _, m := range events {
+		if err := k.Mouse(m, &widgetapi.EventMeta{}); err != nil {
+			t.Fatalf("Mouse(%v) => unexpected error: %v", m, err)
+		}
+	}
+
+	if diff := pretty.Compare([]string{"right", "middle"}, calls); diff != "" {
+		t.Errorf("unexpected calls (-want, +got):\n%s", diff)
+	}
+	if k.IsPressed() {
+		t.Errorf("IsPressed => true, want other buttons not to press the key")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		pressed bool
+		canvas  image.Rectangle
+		want    []string
+		wantBg  cell.Color
+	}{
+		{
+			desc:   "draws the released key",
+			opts:   []Option{Label("K1")},
+			canvas: image.Rect(0, 0, 6, 3),
+			want:   []string{"      ", "  K1  ", "      "},
+			wantBg: cell.ColorNumber(240),
+		},
+		{
+			desc:    "draws the pressed key",
+			opts:    []Option{Label("K1")},
+			pressed: true,
+			canvas:  image.Rect(0, 0, 6, 3),
+			want:    []string{"      ", "  K1  ", "      "},
+			wantBg:  cell.ColorNumber(117),
+		},
+		{
+			desc: "draws with the provided cell options",
+			opts: []Option{
+				PressedCellOpts(cell.BgColor(cell.ColorGreen)),
+			},
+			pressed: true,
+			canvas:  image.Rect(0, 0, 2, 1),
+			want:    []string{"  "},
+			wantBg:  cell.ColorGreen,
+		},
+		{
+			desc:   "shortens the label",
+			opts:   []Option{Label("long")},
+			canvas: image.Rect(0, 0, 3, 1),
+			want:   []string{"lo…"},
+			wantBg: cell.ColorNumber(240),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			k, err := New(tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if tc.pressed {
+				if err := k.Mouse(&terminalapi.Mouse{Button: mouse.ButtonLeft}, &widgetapi.EventMeta{}); err != nil {
+					t.Fatalf("Mouse => unexpected error: %v", err)
+				}
+			}
+
+			c, err := canvas.New(tc.canvas)
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := k.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			buf := ft.BackBuffer()
+			var got []string
+			for y := 0; y < ft.Size().Y; y++ {
+				var row []rune
+				for x := 0; x < ft.Size().X; x++ {
+					cl := buf[x][y]
+					row = append(row, cl.Rune)
+					if cl.Opts.BgColor != tc.wantBg {
+						t.Errorf("cell(%d, %d) has background %v, want %v", x, y, cl.Opts.BgColor, tc.wantBg)
+					}
+				}
+				got = append(got, string(row))
+			}
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// fakeTimer replaces the hold timeout of a key with one that fires when the
+// test calls fire.
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+// fire calls the function the timer was started with, unless it was stopped.
+func (ft *fakeTimer) fire() {
+	if ft.f != nil && !ft.stopped {
+		ft.f()
+	}
+}
+
+func TestKeyboard(t *testing.T) {
+	type step struct {
+		key keyboard.Key
+		// timeout lets the hold timeout pass and fires the pending one, if
+		// any, instead of the key.
+		timeout bool
+		// release calls Release() instead of the key.
+		release bool
+	}
+	press := func(k keyboard.Key) step { return step{key: k} }
+	timeout := step{timeout: true}
+
+	tests := []struct {
+		desc        string
+		opts        []Option
+		steps       []step
+		want        []int32
+		wantPressed bool
+	}{
+		{
+			desc:        "bound key presses",
+			opts:        []Option{Bind('1')},
+			steps:       []step{press('1')},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+		{
+			desc:  "ignores keys that aren't bound",
+			opts:  []Option{Bind('1')},
+			steps: []step{press('2'), press(keyboard.KeyEnter)},
+		},
+		{
+			desc:  "releases after the hold timeout",
+			opts:  []Option{Bind('1', '!')},
+			steps: []step{press('!'), timeout},
+			want:  []int32{1, 0},
+		},
+		{
+			desc:        "repeats extend the hold",
+			opts:        []Option{Bind('1')},
+			steps:       []step{press('1'), press('1'), press('1')},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+		{
+			desc:  "release cancels the hold timeout",
+			opts:  []Option{Bind('1')},
+			steps: []step{press('1'), {release: true}, press('1'), timeout},
+			want:  []int32{1, 0, 1, 0},
+		},
+		{
+			desc:  "latch holds until the next press",
+			opts:  []Option{Bind('1'), Latch()},
+			steps: []step{press('1'), timeout, press('1'), timeout, press('1')},
+			want:  []int32{1, 0, 1},
+			// The timeout doesn't release a latched key.
+			wantPressed: true,
+		},
+		{
+			desc:        "latch ignores the repeats of a held key",
+			opts:        []Option{Bind('1'), Latch()},
+			steps:       []step{press('1'), press('1'), press('1'), press('1'), press('1')},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+		{
+			desc:  "latch releases on a press after release",
+			opts:  []Option{Bind('1'), Latch()},
+			steps: []step{press('1'), {release: true}, press('1'), timeout, press('1')},
+			want:  []int32{1, 0, 1, 0},
+		},
+		{
+			desc:  "toggle also applies to the keyboard",
+			opts:  []Option{Bind('1'), Toggle()},
+			steps: []step{press('1'), timeout, press('1')},
+			want:  []int32{1, 0},
+		},
+		{
+			desc:        "toggle ignores the repeats of a held key",
+			opts:        []Option{Bind('1'), Toggle()},
+			steps:       []step{press('1'), press('1'), press('1')},
+			want:        []int32{1},
+			wantPressed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			opts := append([]Option{Transport(rec), OscRoute("/remote/key/1")}, tc.opts...)
+			k, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			now := time.Unix(0, 0)
+			k.now = func() time.Time { return now }
+			timer := &fakeTimer{}
+			k.after = func(d time.Duration, f func()) func() bool {
+				if d != DefaultHoldTimeout {
+					t.Errorf("hold timeout %v, want %v", d, DefaultHoldTimeout)
+				}
+				timer = &fakeTimer{f: f}
+				ft := timer
+				return func() bool {
+					ft.stopped = true
+					return true
+				}
+			}
+
+			for _, s := range tc.steps {
+				switch {
+				case s.timeout:
+					now = now.Add(DefaultHoldTimeout)
+					timer.fire()
+				case s.release:
+					err = k.Release()
+				default:
+					err = k.Keyboard(&terminalapi.Keyboard{Key: s.key}, &widgetapi.EventMeta{})
+				}
+				if err != nil {
+					t.Fatalf("step %+v => unexpected error: %v", s, err)
+				}
+			}
+
+			got := states(t, rec.Messages(), "/remote/key/1")
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Keyboard => unexpected states (-want, +got):\n%s", diff)
+			}
+			if got := k.IsPressed(); got != tc.wantPressed {
+				t.Errorf("IsPressed => %v, want %v", got, tc.wantPressed)
+			}
+		})
+	}
+}
+
+func TestHoldTimeout(t *testing.T) {
+	k, err := New(Bind('1'), HoldTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	if err := k.Keyboard(&terminalapi.Keyboard{Key: '1'}, &widgetapi.EventMeta{}); err != nil {
+		t.Fatalf("Keyboard => unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for k.IsPressed() {
+		if time.Now().After(deadline) {
+			t.Fatalf("IsPressed => still true after the hold timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClose(t *testing.T) {
+	l, err := transport.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("transport.Listen => unexpected error: %v", err)
+	}
+	defer l.Close()
+	received := make(chan *osc.Message, 10)
+	l.Handle("/remote/key/1", func(msg *osc.Message) {
+		received <- msg
+	})
+
+	port := l.Addr().(*net.UDPAddr).Port
+	k, err := New(OscRoute("/remote/key/1"), OscTarget("127.0.0.1", port))
+	if err != nil {
+		t.Fatalf("New => unexpected error: %v", err)
+	}
+	if err := k.Press(); err != nil {
+		t.Fatalf("Press => unexpected error: %v", err)
+	}
+	if err := k.Release(); err != nil {
+		t.Fatalf("Release => unexpected error: %v", err)
+	}
+	if err := k.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	// Closed keys don't send anymore.
+	if err := k.Press(); err != nil {
+		t.Fatalf("Press => unexpected error: %v", err)
+	}
+
+	var got []interface{}
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case msg := <-received:
+			got = append(got, msg.Arguments...)
+		case <-timeout:
+			t.Fatalf("received %v before the timeout, want the press and the release", got)
+		}
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("received %v after Close, want nothing", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if diff := pretty.Compare([]interface{}{int32(1), int32(0)}, got); diff != "" {
+		t.Errorf("unexpected states (-want, +got):\n%s", diff)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		desc string
+		opts []Option
+		want widgetapi.Options
+	}{
+		{
+			desc: "doesn't want the keyboard without bindings",
+			want: widgetapi.Options{
+				WantKeyboard: widgetapi.KeyScopeNone,
+				WantMouse:    widgetapi.MouseScopeGlobal,
+			},
+		},
+		{
+			desc: "wants all keyboard events with bindings",
+			opts: []Option{Bind('1')},
+			want: widgetapi.Options{
+				WantKeyboard: widgetapi.KeyScopeGlobal,
+				WantMouse:    widgetapi.MouseScopeGlobal,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			k, err := New(tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+			if diff := pretty.Compare(tc.want, k.Options()); diff != "" {
+				t.Errorf("Options => unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package key
+
+// options.go contains configurable options for Key.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/keyboard"
+	"github.com/mum4k/termdash/mouse"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+// Option is used to provide options.
+type Option interface {
+	// set sets the provided option.
+	set(*options)
+}
+
+// options stores the provided options.
+type options struct {
+	label           string
+	cellOpts        []cell.Option
+	pressedCellOpts []cell.Option
+	toggle          bool
+
+	// The keyboard keys that press the key.
+	bindings    []keyboard.Key
+	latch       bool
+	holdTimeout time.Duration
+
+	oscRoute string
+	// The host and port the OSC route is sent to over UDP unless a transport
+	// was provided.
+	hasTarget bool
+	oscAddr   string
+	oscPort   int
+	transport transport.Transport
+
+	// Functions notified when the key is pressed or released.
+	onChange []ChangeFunc
+	// Functions called when other mouse buttons are pressed on the key.
+	onButton []buttonFunc
+}
+
+// buttonFunc is a function called when the mouse button is pressed on the key.
+type buttonFunc struct {
+	button mouse.Button
+	fn     func()
+}
+
+// validate validates the provided options.
+func (o *options) validate() error {
+	if o.holdTimeout <= 0 {
+		return fmt.Errorf("invalid hold timeout %v, must be positive", o.holdTimeout)
+	}
+	for _, fn := range o.onChange {
+		if fn == nil {
+			return fmt.Errorf("invalid OnChange function, must not be nil")
+		}
+	}
+	if o.hasTarget && (o.oscPort < 1 || o.oscPort > 65535) {
+		return fmt.Errorf("invalid OSC target port %d, must be in range 1 <= port <= 65535", o.oscPort)
+	}
+	if o.oscRoute != "" && o.transport == nil && !o.hasTarget {
+		return fmt.Errorf("OSC route %q requires a target or a transport", o.oscRoute)
+	}
+	for _, b := range o.onButton {
+		if b.button != mouse.ButtonRight && b.button != mouse.ButtonMiddle {
+			return fmt.Errorf("invalid OnButton button %v, must be the right or the middle one", b.button)
+		}
+		if b.fn == nil {
+			return fmt.Errorf("invalid OnButton function, must not be nil")
+		}
+	}
+	return nil
+}
+
+// newOptions returns options with the default values set.
+func newOptions() *options {
+	return &options{
+		cellOpts: []cell.Option{
+			cell.FgColor(cell.ColorDefault),
+			cell.BgColor(cell.ColorNumber(240)),
+		},
+		pressedCellOpts: []cell.Option{
+			cell.FgColor(cell.ColorBlack),
+			cell.BgColor(cell.ColorNumber(117)),
+		},
+		holdTimeout: DefaultHoldTimeout,
+	}
+}
+
+// option implements Option.
+type option func(*options)
+
+// set implements Option.set.
+func (o option) set(opts *options) {
+	o(opts)
+}
+
+// Label sets the text displayed in the middle of the key.
+func Label(text string) Option {
+	return option(func(opts *options) {
+		opts.label = text
+	})
+}
+
+// CellOpts sets cell options on the cells of the key while it is released.
+// Defaults to a grey background.
+func CellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.cellOpts = cOpts
+	})
+}
+
+// PressedCellOpts sets cell options on the cells of the key while it is
+// pressed. Defaults to black text on a light blue background.
+func PressedCellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.pressedCellOpts = cOpts
+	})
+}
+
+// Toggle makes the key latch, each press alternates between pressed and
+// released and releasing the mouse button does nothing. By default the key is
+// momentary, it is pressed only while the mouse button is held down.
+func Toggle() Option {
+	return option(func(opts *options) {
+		opts.toggle = true
+	})
+}
+
+// Bind makes the keyboard keys press the key, whichever container is focused.
+// Terminals report when a keyboard key is pressed and repeat the event while it
+// is held down, but don't report when it is released. So the key stays pressed
+// until no event arrived within the hold timeout, see HoldTimeout(), or with
+// Latch() until a bound keyboard key is pressed again. Sources that do report
+// releases can call Release() directly, the release events of the kitty
+// keyboard protocol aren't supported.
+// With Toggle() the keyboard keys toggle the key like the mouse does.
+func Bind(keys ...keyboard.Key) Option {
+	return option(func(opts *options) {
+		opts.bindings = append(opts.bindings, keys...)
+	})
+}
+
+// Latch makes the keyboard keys provided to Bind() hold the key down on the
+// first press and release it on the next, e.g. to hold a shift key while
+// turning the encoders with the mouse. Events that follow the previous one
+// within the hold timeout are repeats of the held keyboard key and don't
+// release it, so the next press must come after the hold timeout.
+func Latch() Option {
+	return option(func(opts *options) {
+		opts.latch = true
+	})
+}
+
+// DefaultHoldTimeout is the default value for the HoldTimeout option. It is
+// well above the usual delays before the keyboard starts repeating a held key,
+// e.g. 660ms on Xorg, with room for the jitter of a remote session.
+const DefaultHoldTimeout = time.Second
+
+// HoldTimeout sets how long after the last event of a bound keyboard key the
+// key is released, and with Latch() or Toggle() how long after it an event is
+// a repeat. Must be longer than the delay before the keyboard starts
+// repeating a held key, otherwise holding a key releases and presses it again.
+func HoldTimeout(d time.Duration) Option {
+	return option(func(opts *options) {
+		opts.holdTimeout = d
+	})
+}
+
+// OscRoute sets the OSC address the key sends to, e.g. /remote/key/N on
+// norns. The key sends an int32 1 when it is pressed and 0 when it is
+// released. Keys without a route don't send anything. The messages are sent
+// to the receiver set by OscTarget() or with the transport provided to
+// Transport().
+func OscRoute(route string) Option {
+	return option(func(opts *options) {
+		opts.oscRoute = route
+	})
+}
+
+// OscTarget sets the host and port of the receiver of the OSC route. The
+// messages are sent asynchronously over UDP, from one queue and connection
+// shared by all the encoders and keys sending to the host and port. Presses
+// and releases are never merged. Call Close() to send the messages still queued before exiting.
+// Ignored when a transport is provided with Transport().
+func OscTarget(addr string, port int) Option {
+	return option(func(opts *options) {
+		opts.hasTarget = true
+		opts.oscAddr = addr
+		opts.oscPort = port
+	})
+}
+
+// Transport sets the transport the OSC messages are sent with instead of UDP
+// to the host and port set by OscTarget(). Allows sharing one transport
+// between multiple controls or recording the messages in tests.
+// The key doesn't close the transport.
+func Transport(t transport.Transport) Option {
+	return option(func(opts *options) {
+		opts.transport = t
+	})
+}
+
+// ChangeFunc is called when the key is pressed or released.
+type ChangeFunc func(pressed bool) error
+
+// OnChange adds a function that is called each time the key is pressed or
+// released, after the OSC route was sent to. Can be provided multiple times,
+// the functions are called in the order they were added.
+// The functions are called without holding the key's lock, so they can call
+// its methods. An error returned by a function is returned from the Mouse,
+// Keyboard, Press or Release call that changed the key, errors of releases
+// after the hold timeout are logged.
+func OnChange(fn ChangeFunc) Option {
+	return option(func(opts *options) {
+		opts.onChange = append(opts.onChange, fn)
+	})
+}
+
+// OnButton adds a function that is called when the right or the middle mouse
+// button is pressed on the key, e.g. to play a Sequence. The left button
+// presses the key and can't be used.
+// The functions are called without holding the key's lock, so they can call
+// its methods. They shouldn't block, because they are called from the
+// handling of the mouse event.
+func OnButton(b mouse.Button, fn func()) Option {
+	return option(func(opts *options) {
+		opts.onButton = append(opts.onButton, buttonFunc{b, fn})
+	})
+}
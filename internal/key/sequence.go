@@ -0,0 +1,125 @@
+package key
+
+// sequence.go contains timed sequences of key presses and releases.
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step is one step of a Sequence.
+type Step struct {
+	// Key is the key the step presses or releases.
+	Key *Key
+	// Pressed is true if the step presses the key and false if it releases
+	// it.
+	Pressed bool
+	// After is how long the step waits after the previous one.
+	After time.Duration
+}
+
+// Sequence presses and releases keys with exact timing from a single trigger,
+// e.g. a chord that holds K1 while K3 is pressed and released or a long press
+// of K2.
+type Sequence []Step
+
+// Play plays the steps of the sequence in order, sending the presses and
+// releases to the OSC routes of the keys. Blocks until the last step was
+// played, start it in a goroutine to keep handling input.
+// Stops when the context is done and returns the context's error.
+// Returns the first error returned by an OnChange function of the keys.
+// However it stops, the keys the sequence pressed and didn't release yet are
+// released.
+func (s Sequence) Play(ctx context.Context) (err error) {
+	pressed := map[*Key]bool{}
+	defer func() {
+		for k := range pressed {
+			if rerr := k.Release(); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}()
+
+	for _, step := range s {
+		if step.After > 0 {
+			t := time.NewTimer(step.After)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+
+		set := step.Key.Release
+		if step.Pressed {
+			set = step.Key.Press
+		}
+		// The key changed even if an OnChange function failed.
+		err := set()
+		if step.Pressed {
+			pressed[step.Key] = true
+		} else {
+			delete(pressed, step.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseSequence parses the steps of a sequence of the keys from the text.
+// The steps are separated by spaces or commas, each is the number of the key
+// starting at 1, 'd' to press it or 'u' to release it and optionally '+' and
+// how long to wait after the previous step as understood by
+// time.ParseDuration. E.g. the chord K1+K3:
+//
+//	1d 3d+50ms 3u+100ms 1u+50ms
+//
+// and a long press of K2:
+//
+//	2d 2u+1s
+func ParseSequence(text string, keys []*Key) (Sequence, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid sequence %q, must have at least one step", text)
+	}
+
+	var seq Sequence
+	for _, f := range fields {
+		spec, wait, hasWait := strings.Cut(f, "+")
+		if len(spec) < 2 {
+			return nil, fmt.Errorf("invalid step %q, must be the key number followed by d or u", f)
+		}
+
+		var step Step
+		switch spec[len(spec)-1] {
+		case 'd':
+			step.Pressed = true
+		case 'u':
+		default:
+			return nil, fmt.Errorf("invalid step %q, must end with d to press or u to release", f)
+		}
+		n, err := strconv.Atoi(spec[:len(spec)-1])
+		if err != nil || n < 1 || n > len(keys) {
+			return nil, fmt.Errorf("invalid key in step %q, must be 1 <= n <= %d", f, len(keys))
+		}
+		step.Key = keys[n-1]
+
+		if hasWait {
+			d, err := time.ParseDuration(wait)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid wait in step %q, must be a non-negative duration", f)
+			}
+			step.After = d
+		}
+		seq = append(seq, step)
+	}
+	return seq, nil
+}
@@ -0,0 +1,213 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+// newKeys returns n keys sending to /remote/key/1 to /remote/key/n with the
+// transport.
+func newKeys(t *testing.T, tr transport.Transport, n int) []*Key {
+	t.Helper()
+	var keys []*Key
+	for i := 1; i <= n; i++ {
+		k, err := New(Transport(tr), OscRoute(fmt.Sprintf("/remote/key/%d", i)))
+		if err != nil {
+			t.Fatalf("New => unexpected error: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestParseSequence(t *testing.T) {
+	keys := newKeys(t, transport.NewRecorder(), 3)
+
+	tests := []struct {
+		desc    string
+		text    string
+		want    Sequence
+		wantErr bool
+	}{
+		{
+			desc: "parses a chord",
+			text: "1d 3d+50ms 3u+100ms 1u+50ms",
+			want: Sequence{
+				{Key: keys[0], Pressed: true},
+				{Key: keys[2], Pressed: true, After: 50 * time.Millisecond},
+				{Key: keys[2], After: 100 * time.Millisecond},
+				{Key: keys[0], After: 50 * time.Millisecond},
+			},
+		},
+		{
+			desc: "parses a long press separated by commas",
+			text: "2d,2u+1s",
+			want: Sequence{
+				{Key: keys[1], Pressed: true},
+				{Key: keys[1], After: time.Second},
+			},
+		},
+		{
+			desc:    "fails on empty sequence",
+			text:    " ,",
+			wantErr: true,
+		},
+		{
+			desc:    "fails on missing key number",
+			text:    "d",
+			wantErr: true,
+		},
+		{
+			desc:    "fails on unknown action",
+			text:    "1x",
+			wantErr: true,
+		},
+		{
+			desc:    "fails on key number out of range",
+			text:    "4d",
+			wantErr: true,
+		},
+		{
+			desc:    "fails on invalid wait",
+			text:    "1d+soon",
+			wantErr: true,
+		},
+		{
+			desc:    "fails on negative wait",
+			text:    "1d+-1s",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseSequence(tc.text, keys)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseSequence => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("ParseSequence => %d steps, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("ParseSequence => step %d is %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// sent is a message sent by a key.
+type sent struct {
+	Address string
+	State   int32
+}
+
+func TestPlay(t *testing.T) {
+	rec := transport.NewRecorder()
+	keys := newKeys(t, rec, 3)
+	seq, err := ParseSequence("1d 3d+1ms 3u+1ms 1u", keys)
+	if err != nil {
+		t.Fatalf("ParseSequence => unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if err := seq.Play(context.Background()); err != nil {
+		t.Fatalf("Play => unexpected error: %v", err)
+	}
+	if took := time.Since(start); took < 2*time.Millisecond {
+		t.Errorf("Play => took %v, want at least the waits of 2ms", took)
+	}
+
+	var got []sent
+	for _, m := range rec.Messages() {
+		got = append(got, sent{m.Address, m.Arguments[0].(int32)})
+	}
+	want := []sent{
+		{"/remote/key/1", 1},
+		{"/remote/key/3", 1},
+		{"/remote/key/3", 0},
+		{"/remote/key/1", 0},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("Play => unexpected messages (-want, +got):\n%s", diff)
+	}
+}
+
+func TestPlayCanceled(t *testing.T) {
+	keys := newKeys(t, transport.NewRecorder(), 2)
+	seq, err := ParseSequence("1d 2d 2u+1h 1u", keys)
+	if err != nil {
+		t.Fatalf("ParseSequence => unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- seq.Play(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !keys[1].IsPressed() {
+		if time.Now().After(deadline) {
+			t.Fatalf("IsPressed => the sequence didn't press the key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Play => %v, want %v", err, context.Canceled)
+	}
+	for i, k := range keys {
+		if k.IsPressed() {
+			t.Errorf("key %d is still pressed after the sequence was canceled", i+1)
+		}
+	}
+}
+
+func TestPlayFails(t *testing.T) {
+	errFail := errors.New("fail")
+	var keys []*Key
+	for i := 1; i <= 2; i++ {
+		opts := []Option{
+			Transport(transport.NewRecorder()),
+			OscRoute(fmt.Sprintf("/remote/key/%d", i)),
+		}
+		if i == 2 {
+			opts = append(opts, OnChange(func(pressed bool) error {
+				if pressed {
+					return errFail
+				}
+				return nil
+			}))
+		}
+		k, err := New(opts...)
+		if err != nil {
+			t.Fatalf("New => unexpected error: %v", err)
+		}
+		keys = append(keys, k)
+	}
+	seq, err := ParseSequence("1d 2d 2u 1u", keys)
+	if err != nil {
+		t.Fatalf("ParseSequence => unexpected error: %v", err)
+	}
+
+	if err := seq.Play(context.Background()); err != errFail {
+		t.Errorf("Play => %v, want %v", err, errFail)
+	}
+	for i, k := range keys {
+		if k.IsPressed() {
+			t.Errorf("key %d is still pressed after the sequence failed", i+1)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package readout
+
+// options.go contains configurable options for Readout.
+
+import (
+	"github.com/mum4k/termdash/cell"
+)
+
+// Option is used to provide options.
+type Option interface {
+	// set sets the provided option.
+	set(*options)
+}
+
+// options stores the provided options.
+type options struct {
+	label         string
+	cellOpts      []cell.Option
+	heldCellOpts  []cell.Option
+	errorCellOpts []cell.Option
+}
+
+// newOptions returns options with the default values set.
+func newOptions() *options {
+	return &options{
+		heldCellOpts: []cell.Option{
+			cell.Bold(),
+		},
+		errorCellOpts: []cell.Option{
+			cell.FgColor(cell.ColorRed),
+		},
+	}
+}
+
+// option implements Option.
+type option func(*options)
+
+// set implements Option.set.
+func (o option) set(opts *options) {
+	o(opts)
+}
+
+// Label sets the text displayed before the state, e.g. the name of the
+// control.
+func Label(text string) Option {
+	return option(func(opts *options) {
+		opts.label = text
+	})
+}
+
+// CellOpts sets cell options on the cells of the state.
+func CellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.cellOpts = cOpts
+	})
+}
+
+// HeldCellOpts sets cell options on the cells of the state while the control
+// is held down. Defaults to bold text.
+func HeldCellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.heldCellOpts = cOpts
+	})
+}
+
+// ErrorCellOpts sets cell options on the cells of the error. Defaults to red
+// text.
+func ErrorCellOpts(cOpts ...cell.Option) Option {
+	return option(func(opts *options) {
+		opts.errorCellOpts = cOpts
+	})
+}
@@ -0,0 +1,136 @@
+// Package readout is a widget that displays the state of a control kept in a
+// state.Model, the last value it sent, whether it is held down and the error
+// of sending.
+package readout
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/mum4k/termdash/align"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/private/alignfor"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/draw"
+	"github.com/mum4k/termdash/terminal/terminalapi"
+	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/state"
+)
+
+// Readout displays the state of the control that sends to an OSC route on a
+// line, e.g. "K1 held, sent 1", and the error of sending on the next line. On
+// a single line the error replaces the state.
+//
+// Implements widgetapi.Widget. This object is thread-safe.
+type Readout struct {
+	// model keeps the state of the control.
+	model *state.Model
+	// route is the OSC route the control sends to.
+	route string
+
+	// opts are the provided options.
+	opts *options
+}
+
+// New returns a new Readout of the control that sends to the route.
+func New(m *state.Model, route string, opts ...Option) (*Readout, error) {
+	if m == nil {
+		return nil, errors.New("invalid model, must not be nil")
+	}
+	if route == "" {
+		return nil, errors.New("invalid route, must not be empty")
+	}
+	opt := newOptions()
+	for _, o := range opts {
+		o.set(opt)
+	}
+	return &Readout{
+		model: m,
+		route: route,
+		opts:  opt,
+	}, nil
+}
+
+// line is a line of the readout.
+type line struct {
+	text  string
+	cOpts []cell.Option
+}
+
+// lines returns the lines that display the state of the control.
+func (r *Readout) lines(c state.Control) []line {
+	var parts []string
+	if r.opts.label != "" {
+		parts = append(parts, r.opts.label)
+	}
+	var status []string
+	if c.Held {
+		status = append(status, "held")
+	}
+	if c.Args == nil {
+		status = append(status, "idle")
+	} else {
+		args := make([]string, len(c.Args))
+		for i, a := range c.Args {
+			args[i] = fmt.Sprint(a)
+		}
+		status = append(status, "sent "+strings.Join(args, " "))
+	}
+	parts = append(parts, strings.Join(status, ", "))
+
+	cOpts := r.opts.cellOpts
+	if c.Held {
+		cOpts = append(append([]cell.Option{}, cOpts...), r.opts.heldCellOpts...)
+	}
+	lines := []line{
+		{strings.Join(parts, " "), cOpts},
+	}
+	if c.Err != nil {
+		lines = append(lines, line{fmt.Sprintf("error: %v", c.Err), r.opts.errorCellOpts})
+	}
+	return lines
+}
+
+// Draw draws the Readout widget onto the canvas.
+// Implements widgetapi.Widget.Draw.
+func (r *Readout) Draw(cvs *canvas.Canvas, _ *widgetapi.Meta) error {
+	ar := cvs.Area()
+	lines := r.lines(r.model.Control(r.route))
+	if len(lines) > ar.Dy() {
+		// The error is more important than the state.
+		lines = lines[len(lines)-ar.Dy():]
+	}
+
+	for i, l := range lines {
+		lineAr := image.Rect(ar.Min.X, ar.Min.Y+i, ar.Max.X, ar.Min.Y+i+1)
+		start, err := alignfor.Text(lineAr, l.text, align.HorizontalCenter, align.VerticalTop)
+		if err != nil {
+			return fmt.Errorf("alignfor.Text => %v", err)
+		}
+		if err := draw.Text(cvs, l.text, start,
+			draw.TextOverrunMode(draw.OverrunModeThreeDot),
+			draw.TextMaxX(ar.Max.X),
+			draw.TextCellOpts(l.cOpts...),
+		); err != nil {
+			return fmt.Errorf("draw.Text => %v", err)
+		}
+	}
+	return nil
+}
+
+// Keyboard implements widgetapi.Widget.Keyboard.
+func (*Readout) Keyboard(_ *terminalapi.Keyboard, _ *widgetapi.EventMeta) error {
+	return errors.New("the Readout widget doesn't support keyboard events")
+}
+
+// Mouse implements widgetapi.Widget.Mouse.
+func (*Readout) Mouse(_ *terminalapi.Mouse, _ *widgetapi.EventMeta) error {
+	return errors.New("the Readout widget doesn't support mouse events")
+}
+
+// Options implements widgetapi.Widget.Options.
+func (*Readout) Options() widgetapi.Options {
+	return widgetapi.Options{}
+}
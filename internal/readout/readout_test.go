@@ -0,0 +1,155 @@
+package readout
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/mum4k/termdash/cell"
+	"github.com/mum4k/termdash/private/canvas"
+	"github.com/mum4k/termdash/private/faketerm"
+	"github.com/mum4k/termdash/widgetapi"
+	"github.com/zzsnzmn/osctl/internal/state"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		model   *state.Model
+		route   string
+		wantErr bool
+	}{
+		{
+			desc:  "succeeds with a model and a route",
+			model: state.New(),
+			route: "/remote/key/1",
+		},
+		{
+			desc:    "fails without a model",
+			route:   "/remote/key/1",
+			wantErr: true,
+		},
+		{
+			desc:    "fails without a route",
+			model:   state.New(),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := New(tc.model, tc.route)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("New => unexpected error: %v, wantErr: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDraw(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opts     []Option
+		sent     *osc.Message
+		sendErr  error
+		held     bool
+		canvas   image.Rectangle
+		want     []string
+		wantFg   cell.Color
+		wantBold bool
+	}{
+		{
+			desc:   "draws an idle control",
+			opts:   []Option{Label("E1")},
+			canvas: image.Rect(0, 0, 9, 1),
+			want:   []string{" E1 idle "},
+		},
+		{
+			desc:   "draws the last value sent",
+			opts:   []Option{Label("E1")},
+			sent:   osc.NewMessage("/route", int32(-3)),
+			canvas: image.Rect(0, 0, 12, 2),
+			want:   []string{" E1 sent -3 ", "            "},
+		},
+		{
+			desc:     "draws a held control",
+			sent:     osc.NewMessage("/route", int32(1)),
+			held:     true,
+			canvas:   image.Rect(0, 0, 12, 1),
+			want:     []string{"held, sent 1"},
+			wantBold: true,
+		},
+		{
+			desc:    "draws the error below the state",
+			opts:    []Option{Label("K1")},
+			sent:    osc.NewMessage("/route", int32(0)),
+			sendErr: transport.ErrClosed,
+			canvas:  image.Rect(0, 0, 9, 2),
+			want:    []string{"K1 sent 0", "error: t…"},
+		},
+		{
+			desc:    "draws only the error on a single line",
+			opts:    []Option{Label("K1"), ErrorCellOpts(cell.FgColor(cell.ColorBlue))},
+			sent:    osc.NewMessage("/route", int32(0)),
+			sendErr: transport.ErrClosed,
+			canvas:  image.Rect(0, 0, 9, 1),
+			want:    []string{"error: t…"},
+			wantFg:  cell.ColorBlue,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			m := state.New()
+			if tc.sent != nil {
+				m.Sent(tc.sent, tc.sendErr)
+			}
+			m.SetHeld("/route", tc.held)
+			r, err := New(m, "/route", tc.opts...)
+			if err != nil {
+				t.Fatalf("New => unexpected error: %v", err)
+			}
+
+			c, err := canvas.New(tc.canvas)
+			if err != nil {
+				t.Fatalf("canvas.New => unexpected error: %v", err)
+			}
+			if err := r.Draw(c, &widgetapi.Meta{}); err != nil {
+				t.Fatalf("Draw => unexpected error: %v", err)
+			}
+			ft := faketerm.MustNew(c.Size())
+			if err := c.Apply(ft); err != nil {
+				t.Fatalf("Apply => unexpected error: %v", err)
+			}
+
+			buf := ft.BackBuffer()
+			var got []string
+			for y := 0; y < ft.Size().Y; y++ {
+				var row []rune
+				for x := 0; x < ft.Size().X; x++ {
+					cl := buf[x][y]
+					if cl.Rune == 0 {
+						row = append(row, ' ')
+						continue
+					}
+					row = append(row, cl.Rune)
+					if cl.Rune == ' ' || y > 0 {
+						continue
+					}
+					if cl.Opts.FgColor != tc.wantFg {
+						t.Errorf("cell(%d, %d) has foreground %v, want %v", x, y, cl.Opts.FgColor, tc.wantFg)
+					}
+					if cl.Opts.Bold != tc.wantBold {
+						t.Errorf("cell(%d, %d) bold => %v, want %v", x, y, cl.Opts.Bold, tc.wantBold)
+					}
+				}
+				got = append(got, string(row))
+			}
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("Draw => unexpected rows (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package state keeps the state of the controls of a dashboard by the OSC
+// route they send to, what each sent last, whether it is held down and the
+// error of sending, for the widgets that display it.
+package state
+
+import (
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+// Control is the state of the control that sends to an OSC route.
+type Control struct {
+	// Args are the arguments of the last message sent to the route, nil
+	// until one was sent.
+	Args []interface{}
+	// Held is true while the control, e.g. a key, is held down.
+	Held bool
+	// Err is the error of the last send to the route, nil if it succeeded.
+	Err error
+}
+
+// Model stores the state of the controls. The controls update it through the
+// transport returned by Transport() and through SetHeld(), e.g. from an
+// OnChange function, and the widgets read it when they draw.
+//
+// This object is thread-safe.
+type Model struct {
+	// mu protects the fields below.
+	mu       sync.Mutex
+	controls map[string]Control
+}
+
+// New returns a new Model.
+func New() *Model {
+	return &Model{
+		controls: map[string]Control{},
+	}
+}
+
+// Control returns the state of the control that sends to the route.
+func (m *Model) Control(route string) Control {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.controls[route]
+	if c.Args != nil {
+		c.Args = append([]interface{}{}, c.Args...)
+	}
+	return c
+}
+
+// SetHeld records whether the control that sends to the route is held down.
+func (m *Model) SetHeld(route string, held bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.controls[route]
+	c.Held = held
+	m.controls[route] = c
+}
+
+// Sent records the messages of the packet as sent to their addresses, with
+// the error of sending them or nil.
+func (m *Model) Sent(p osc.Packet, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent(p, err)
+}
+
+// sent records the messages of the packet and of its nested bundles.
+// The caller must hold m.mu.
+func (m *Model) sent(p osc.Packet, err error) {
+	switch p := p.(type) {
+	case *osc.Message:
+		c := m.controls[p.Address]
+		c.Args = append([]interface{}{}, p.Arguments...)
+		c.Err = err
+		m.controls[p.Address] = c
+	case *osc.Bundle:
+		for _, msg := range p.Messages {
+			m.sent(msg, err)
+		}
+		for _, b := range p.Bundles {
+			m.sent(b, err)
+		}
+	}
+}
+
+// Transport returns a transport that sends with the provided one and records
+// every packet and the error of sending it in the model. Wrap the transport
+// closest to the network, e.g. UDP, to record what actually went out.
+func (m *Model) Transport(t transport.Transport) transport.Transport {
+	return &tracker{m: m, t: t}
+}
+
+// tracker records the packets sent with a transport in the model.
+//
+// Implements transport.Transport. This object is thread-safe.
+type tracker struct {
+	m *Model
+	t transport.Transport
+}
+
+// Send implements transport.Transport.Send.
+func (tr *tracker) Send(p osc.Packet) error {
+	err := tr.t.Send(p)
+	tr.m.Sent(p, err)
+	return err
+}
+
+// Close implements transport.Transport.Close.
+func (tr *tracker) Close() error {
+	return tr.t.Close()
+}
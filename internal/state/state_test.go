@@ -0,0 +1,98 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/zzsnzmn/osctl/internal/transport"
+)
+
+func TestTransport(t *testing.T) {
+	bundle := osc.NewBundle(time.Time{})
+	bundle.Messages = []*osc.Message{
+		osc.NewMessage("/remote/enc/1", int32(3)),
+		osc.NewMessage("/remote/key/1", int32(1)),
+	}
+
+	tests := []struct {
+		desc    string
+		packets []osc.Packet
+		closed  bool
+		route   string
+		want    Control
+	}{
+		{
+			desc:  "nothing sent",
+			route: "/remote/enc/1",
+			want:  Control{},
+		},
+		{
+			desc: "records the last message sent to the route",
+			packets: []osc.Packet{
+				osc.NewMessage("/remote/enc/1", int32(1)),
+				osc.NewMessage("/remote/enc/2", int32(2)),
+				osc.NewMessage("/remote/enc/1", int32(-1)),
+			},
+			route: "/remote/enc/1",
+			want:  Control{Args: []interface{}{int32(-1)}},
+		},
+		{
+			desc:    "records the messages of bundles",
+			packets: []osc.Packet{bundle},
+			route:   "/remote/key/1",
+			want:    Control{Args: []interface{}{int32(1)}},
+		},
+		{
+			desc: "records the error of sending",
+			packets: []osc.Packet{
+				osc.NewMessage("/remote/key/1", int32(0)),
+			},
+			closed: true,
+			route:  "/remote/key/1",
+			want: Control{
+				Args: []interface{}{int32(0)},
+				Err:  transport.ErrClosed,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			rec := transport.NewRecorder()
+			if tc.closed {
+				if err := rec.Close(); err != nil {
+					t.Fatalf("Close => unexpected error: %v", err)
+				}
+			}
+			m := New()
+			tr := m.Transport(rec)
+			for _, p := range tc.packets {
+				if err := tr.Send(p); err != nil && !tc.closed {
+					t.Fatalf("Send => unexpected error: %v", err)
+				}
+			}
+
+			if diff := pretty.Compare(tc.want, m.Control(tc.route)); diff != "" {
+				t.Errorf("Control => unexpected state (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetHeld(t *testing.T) {
+	m := New()
+	m.Sent(osc.NewMessage("/remote/key/1", int32(1)), nil)
+	m.SetHeld("/remote/key/1", true)
+
+	want := Control{Args: []interface{}{int32(1)}, Held: true}
+	if diff := pretty.Compare(want, m.Control("/remote/key/1")); diff != "" {
+		t.Errorf("Control => unexpected state (-want, +got):\n%s", diff)
+	}
+
+	m.SetHeld("/remote/key/1", false)
+	if got := m.Control("/remote/key/1").Held; got {
+		t.Errorf("Control.Held => %v, want false", got)
+	}
+}
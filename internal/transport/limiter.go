@@ -0,0 +1,275 @@
+package transport
+
+// limiter.go contains a Transport that limits the rate of messages.
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Stats are statistics about the messages that went through a Limiter.
+// The messages received and neither sent nor coalesced are still pending.
+type Stats struct {
+	// Received is the number of messages sent to the limiter.
+	Received int
+	// Sent is the number of messages the limiter sent, including the ones
+	// sent in bundles.
+	Sent int
+	// Coalesced is the number of messages that were merged into another
+	// message instead of being sent.
+	Coalesced int
+}
+
+// stopper stops a scheduled function, implemented by *time.Timer.
+type stopper interface {
+	Stop() bool
+}
+
+// Limiter sends messages with another transport at most at the maximum rate
+// per address. Messages to an address that was sent to less than a tick ago
+// are merged with the merge function and sent when the tick is over, so a
+// fast stream of deltas results in one message per tick carrying the same
+// net change. Messages that can't be merged are sent one per tick in order.
+// The messages to different addresses that are due at the same time are sent
+// in the order they arrived. Bundles are sent right away.
+//
+// Implements Transport. This object is thread-safe.
+type Limiter struct {
+	// t is the transport the messages are sent with.
+	t Transport
+	// interval is the minimum time between two messages to an address.
+	interval time.Duration
+	// opts are the provided options.
+	opts *options
+
+	// now returns the current time, replaceable in tests.
+	now func() time.Time
+	// afterFunc calls the function after the duration, replaceable in tests.
+	afterFunc func(time.Duration, func()) stopper
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// routes are the addresses sent to.
+	routes map[string]*route
+	// seq numbers the pending messages in the order they arrived.
+	seq int
+	// timer flushes the pending messages, nil if none are scheduled.
+	timer  stopper
+	stats  Stats
+	closed bool
+}
+
+// route is an address the limiter sends to.
+type route struct {
+	// last is when a message was last sent to the address.
+	last time.Time
+	// pending are the messages waiting for the tick to end, oldest first.
+	pending []*pending
+}
+
+// pending is a message waiting for the tick of its route to end.
+type pending struct {
+	msg *osc.Message
+	// seq is the arrival of the first of the messages merged into msg.
+	seq int
+}
+
+// NewLimiter returns a new Limiter that sends with the transport at most
+// maxRate messages per second to each address.
+// The limiter owns the transport and closes it when it is closed.
+func NewLimiter(t Transport, maxRate float64, opts ...Option) (*Limiter, error) {
+	if maxRate <= 0 {
+		return nil, fmt.Errorf("invalid max rate %v, must be a positive number", maxRate)
+	}
+	opt := newOptions()
+	for _, o := range opts {
+		o.set(opt)
+	}
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		t:        t,
+		interval: time.Duration(float64(time.Second) / maxRate),
+		opts:     opt,
+		now:      time.Now,
+		afterFunc: func(d time.Duration, f func()) stopper {
+			return time.AfterFunc(d, f)
+		},
+		routes: map[string]*route{},
+	}, nil
+}
+
+// Send sends the message right away if its address wasn't sent to within the
+// last tick, otherwise it is merged into the pending messages.
+// Implements Transport.Send.
+func (l *Limiter) Send(p osc.Packet) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrClosed
+	}
+
+	msg, ok := p.(*osc.Message)
+	if !ok {
+		n := len(appendMessages(nil, p))
+		l.stats.Received += n
+		l.stats.Sent += n
+		l.mu.Unlock()
+		return l.t.Send(p)
+	}
+
+	l.stats.Received++
+	now := l.now()
+	r, ok := l.routes[msg.Address]
+	if !ok {
+		r = &route{}
+		l.routes[msg.Address] = r
+	}
+	if len(r.pending) == 0 && now.Sub(r.last) >= l.interval {
+		r.last = now
+		l.stats.Sent++
+		l.mu.Unlock()
+		return l.t.Send(msg)
+	}
+
+	l.add(r, msg)
+	l.schedule(now)
+	l.mu.Unlock()
+	return nil
+}
+
+// Close sends all the pending messages and closes the transport.
+// Implements Transport.Close.
+func (l *Limiter) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	now := l.now()
+	msgs := l.due(now, true)
+	l.mu.Unlock()
+
+	l.send(now, msgs)
+	return l.t.Close()
+}
+
+// Stats returns the statistics of the messages sent so far.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.stats
+}
+
+// add merges the message into the last pending message of the route or
+// appends it if they can't be merged.
+// The caller must hold l.mu.
+func (l *Limiter) add(r *route, msg *osc.Message) {
+	if n := len(r.pending); n > 0 {
+		if merged, ok := l.opts.merge(r.pending[n-1].msg, msg); ok {
+			r.pending[n-1].msg = merged
+			l.stats.Coalesced++
+			return
+		}
+	}
+	l.seq++
+	r.pending = append(r.pending, &pending{msg: msg, seq: l.seq})
+}
+
+// schedule schedules flushing the pending messages at the end of the tick of
+// the route that is due first, unless it is already scheduled.
+// The caller must hold l.mu.
+func (l *Limiter) schedule(now time.Time) {
+	if l.timer != nil {
+		return
+	}
+	var next time.Time
+	for _, r := range l.routes {
+		if len(r.pending) == 0 {
+			continue
+		}
+		if due := r.last.Add(l.interval); next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	l.timer = l.afterFunc(next.Sub(now), l.flush)
+}
+
+// flush sends the pending messages that are due and schedules the next flush.
+func (l *Limiter) flush() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.timer = nil
+	now := l.now()
+	msgs := l.due(now, false)
+	l.schedule(now)
+	l.mu.Unlock()
+
+	l.send(now, msgs)
+}
+
+// due removes and returns the first pending message of each route whose tick
+// is over, or all the pending messages if all is true. The messages are
+// ordered by their arrival, so that e.g. releases of keys on different routes
+// are sent in the order they were made.
+// The caller must hold l.mu.
+func (l *Limiter) due(now time.Time, all bool) []*osc.Message {
+	var ps []*pending
+	for _, r := range l.routes {
+		if len(r.pending) == 0 || !all && now.Sub(r.last) < l.interval {
+			continue
+		}
+		if all {
+			ps = append(ps, r.pending...)
+			r.pending = nil
+		} else {
+			ps = append(ps, r.pending[0])
+			r.pending = r.pending[1:]
+		}
+		r.last = now
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].seq < ps[j].seq
+	})
+
+	var msgs []*osc.Message
+	for _, p := range ps {
+		msgs = append(msgs, p.msg)
+	}
+	l.stats.Sent += len(msgs)
+	return msgs
+}
+
+// send sends the messages, as one bundle if enabled.
+func (l *Limiter) send(now time.Time, msgs []*osc.Message) {
+	if l.opts.bundle && len(msgs) > 1 {
+		b := osc.NewBundle(now)
+		b.Messages = msgs
+		if err := l.t.Send(b); err != nil {
+			l.opts.onError(err)
+		}
+		return
+	}
+	for _, msg := range msgs {
+		if err := l.t.Send(msg); err != nil {
+			l.opts.onError(err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// fakeTimer is a function scheduled on the fake clock.
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+// Stop implements stopper.Stop.
+func (ft *fakeTimer) Stop() bool {
+	ft.stopped = true
+	return true
+}
+
+// fakeClock replaces the clock and the timers of a limiter.
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// install makes the limiter use the fake clock.
+func (fc *fakeClock) install(l *Limiter) {
+	l.now = func() time.Time { return fc.now }
+	l.afterFunc = func(d time.Duration, f func()) stopper {
+		ft := &fakeTimer{at: fc.now.Add(d), f: f}
+		fc.timers = append(fc.timers, ft)
+		return ft
+	}
+}
+
+// advance moves the clock forward, calling the functions that become due in
+// the order they are due.
+func (fc *fakeClock) advance(d time.Duration) {
+	to := fc.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, ft := range fc.timers {
+			if ft.stopped || ft.at.After(to) {
+				continue
+			}
+			if next == nil || ft.at.Before(next.at) {
+				next = ft
+			}
+		}
+		if next == nil {
+			break
+		}
+		next.stopped = true
+		fc.now = next.at
+		next.f()
+	}
+	fc.now = to
+}
+
+// timedMessage is a message sent after some time passed.
+type timedMessage struct {
+	after time.Duration
+	msg   *osc.Message
+}
+
+func TestLimiter(t *testing.T) {
+	enc := func(d int32) *osc.Message { return message("/remote/enc/1", d) }
+	enc2 := func(d int32) *osc.Message { return message("/remote/enc/2", d) }
+	key := func(s int32) *osc.Message { return message("/remote/key/1", s) }
+	key3 := func(s int32) *osc.Message { return message("/remote/key/3", s) }
+	noMerge := func(_, _ *osc.Message) (*osc.Message, bool) { return nil, false }
+	ms := time.Millisecond
+
+	tests := []struct {
+		desc        string
+		maxRate     float64
+		opts        []Option
+		wantNewErr  bool
+		sent        []timedMessage
+		wait        time.Duration
+		close       bool
+		want        []*osc.Message
+		wantPackets int
+		wantStats   Stats
+	}{
+		{
+			desc:       "fails on zero max rate",
+			maxRate:    0,
+			wantNewErr: true,
+		},
+		{
+			desc:       "fails on nil merge function",
+			maxRate:    10,
+			opts:       []Option{Merge(nil)},
+			wantNewErr: true,
+		},
+		{
+			desc:        "sends the first message right away",
+			maxRate:     10,
+			sent:        []timedMessage{{0, enc(1)}},
+			want:        []*osc.Message{enc(1)},
+			wantPackets: 1,
+			wantStats:   Stats{Received: 1, Sent: 1},
+		},
+		{
+			desc:    "sums deltas within a tick",
+			maxRate: 10,
+			sent: []timedMessage{
+				{0, enc(1)},
+				{10 * ms, enc(2)},
+				{10 * ms, enc(3)},
+				{10 * ms, enc(-1)},
+			},
+			wait:        time.Second,
+			want:        []*osc.Message{enc(1), enc(4)},
+			wantPackets: 2,
+			wantStats:   Stats{Received: 4, Sent: 2, Coalesced: 2},
+		},
+		{
+			desc:    "keeps the deltas pending until the tick is over",
+			maxRate: 10,
+			sent: []timedMessage{
+				{0, enc(1)},
+				{10 * ms, enc(2)},
+				{10 * ms, enc(3)},
+			},
+			wait:        50 * ms,
+			want:        []*osc.Message{enc(1)},
+			wantPackets: 1,
+			wantStats:   Stats{Received: 3, Sent: 1, Coalesced: 1},
+		},
+		{
+			desc:    "sends right away once the tick is over",
+			maxRate: 10,
+			sent: []timedMessage{
+				{0, enc(1)},
+				{150 * ms, enc(2)},
+			},
+			want:        []*osc.Message{enc(1), enc(2)},
+			wantPackets: 2,
+			wantStats:   Stats{Received: 2, Sent: 2},
+		},
+		{
+			desc:    "limits each address separately",
+			maxRate: 10,
+			sent: []timedMessage{
+				{0, enc(1)},
+				{0, enc2(1)},
+				{0, key(1)},
+			},
+			want:        []*osc.Message{enc(1), enc2(1), key(1)},
+			wantPackets: 3,
+			wantStats:   Stats{Received: 3, Sent: 3},
+		},
+		{
+			desc:    "sends messages that can't be merged one per tick",
+			maxRate: 10,
+			opts:    []Option{Merge(noMerge)},
+			sent: []timedMessage{
+				{0, key(1)},
+				{10 * ms, key(0)},
+				{10 * ms, key(1)},
+			},
+			wait:        150 * ms,
+			want:        []*osc.Message{key(1), key(0)},
+			wantPackets: 2,
+			wantStats:   Stats{Received: 3, Sent: 2},
+		},
+		{
+			desc:    "sends the messages that are due together in the order they arrived",
+			maxRate: 30,
+			sent: []timedMessage{
+				{0, key(1)},
+				{0, key3(1)},
+				{10 * ms, key3(0)},
+				{10 * ms, key(0)},
+			},
+			wait:        time.Second,
+			want:        []*osc.Message{key(1), key3(1), key3(0), key(0)},
+			wantPackets: 4,
+			wantStats:   Stats{Received: 4, Sent: 4},
+		},
+		{
+			desc:    "close sends the pending messages in the order they arrived",
+			maxRate: 10,
+			opts:    []Option{Merge(noMerge)},
+			sent: []timedMessage{
+				{0, key3(1)},
+				{0, key(1)},
+				{10 * ms, key(0)},
+				{10 * ms, key3(0)},
+				{10 * ms, key(1)},
+			},
+			close:       true,
+			want:        []*osc.Message{key3(1), key(1), key(0), key3(0), key(1)},
+			wantPackets: 5,
+			wantStats:   Stats{Received: 5, Sent: 5},
+		},
+		{
+			desc:    "bundles the messages that are due together",
+			maxRate: 10,
+			opts:    []Option{Bundle()},
+			sent: []timedMessage{
+				{0, enc(1)},
+				{0, enc2(1)},
+				{10 * ms, enc(2)},
+				{0, enc2(-2)},
+			},
+			wait:        time.Second,
+			want:        []*osc.Message{enc(1), enc2(1), enc(2), enc2(-2)},
+			wantPackets: 3,
+			wantStats:   Stats{Received: 4, Sent: 4},
+		},
+		{
+			desc:    "close sends the pending messages",
+			maxRate: 10,
+			opts:    []Option{Merge(noMerge)},
+			sent: []timedMessage{
+				{0, key(1)},
+				{10 * ms, key(0)},
+				{10 * ms, key(1)},
+			},
+			close:       true,
+			want:        []*osc.Message{key(1), key(0), key(1)},
+			wantPackets: 3,
+			wantStats:   Stats{Received: 3, Sent: 3},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := NewRecorder()
+			l, err := NewLimiter(r, tc.maxRate, tc.opts...)
+			if (err != nil) != tc.wantNewErr {
+				t.Fatalf("NewLimiter => unexpected error: %v, wantNewErr: %v", err, tc.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+			fc := &fakeClock{now: time.Unix(0, 0)}
+			fc.install(l)
+
+			for _, tm := range tc.sent {
+				fc.advance(tm.after)
+				if err := l.Send(tm.msg); err != nil {
+					t.Fatalf("Send => unexpected error: %v", err)
+				}
+			}
+			fc.advance(tc.wait)
+			if tc.close {
+				if err := l.Close(); err != nil {
+					t.Fatalf("Close => unexpected error: %v", err)
+				}
+			}
+
+			if diff := pretty.Compare(tc.want, r.Messages()); diff != "" {
+				t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+			}
+			if got := len(r.Packets()); got != tc.wantPackets {
+				t.Errorf("sent %d packets, want %d", got, tc.wantPackets)
+			}
+			if diff := pretty.Compare(tc.wantStats, l.Stats()); diff != "" {
+				t.Errorf("Stats => unexpected diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLimiterClosed(t *testing.T) {
+	r := NewRecorder()
+	l, err := NewLimiter(r, 10)
+	if err != nil {
+		t.Fatalf("NewLimiter => unexpected error: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	if err := l.Send(message("/a")); err != ErrClosed {
+		t.Errorf("Send => %v, want %v", err, ErrClosed)
+	}
+	if !r.Closed() {
+		t.Errorf("Close didn't close the transport")
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+// listener.go contains a receiver of OSC packets.
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// HandlerFunc handles a message received by a Listener.
+type HandlerFunc func(msg *osc.Message)
+
+// handler is a HandlerFunc registered with a Listener. Compared by pointer
+// when removed.
+type handler struct {
+	fn HandlerFunc
+}
+
+// Listener receives OSC packets on a local UDP port and calls the handlers
+// registered for the addresses of the messages. The messages of bundles are
+// handled one by one, bundle time tags are ignored.
+//
+// This object is thread-safe.
+type Listener struct {
+	// conn is the connection the packets are received on.
+	conn net.PacketConn
+	// opts are the provided options.
+	opts *options
+
+	// mu protects handlers.
+	mu       sync.Mutex
+	handlers map[string][]*handler
+
+	// done is closed when the receiving goroutine exits.
+	done chan struct{}
+}
+
+// Listen returns a new Listener receiving on the local address, e.g. ":10112"
+// for port 10112 on all interfaces.
+// Errors receiving or parsing packets are reported to the OnError function.
+func Listen(addr string, opts ...Option) (*Listener, error) {
+	opt := newOptions()
+	for _, o := range opts {
+		o.set(opt)
+	}
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{
+		conn:     conn,
+		opts:     opt,
+		handlers: map[string][]*handler{},
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// Addr returns the local address the listener receives on.
+func (l *Listener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Handle registers the function to be called with the messages received on
+// the address. The address must match exactly, patterns aren't supported.
+// Multiple functions can be registered for an address, they are called in the
+// order they were registered from the listener's goroutine.
+// Returns a function that removes the registration.
+func (l *Listener) Handle(address string, fn HandlerFunc) (remove func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h := &handler{fn: fn}
+	l.handlers[address] = append(l.handlers[address], h)
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		hs := l.handlers[address]
+		for i, other := range hs {
+			if other == h {
+				l.handlers[address] = append(hs[:i:i], hs[i+1:]...)
+				break
+			}
+		}
+		if len(l.handlers[address]) == 0 {
+			delete(l.handlers, address)
+		}
+	}
+}
+
+// Dispatch calls the handlers for the messages of the packet as if it was
+// received. The handlers are called from the calling goroutine.
+func (l *Listener) Dispatch(p osc.Packet) {
+	for _, msg := range appendMessages(nil, p) {
+		l.mu.Lock()
+		hs := append([]*handler(nil), l.handlers[msg.Address]...)
+		l.mu.Unlock()
+
+		for _, h := range hs {
+			h.fn(msg)
+		}
+	}
+}
+
+// Close stops receiving and waits until the handlers of the last packet
+// returned. Must not be called from a handler.
+func (l *Listener) Close() error {
+	err := l.conn.Close()
+	<-l.done
+	return err
+}
+
+// run receives packets until the listener is closed.
+func (l *Listener) run() {
+	defer close(l.done)
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := l.conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			l.opts.onError(err)
+			continue
+		}
+		p, err := osc.ParsePacket(string(buf[:n]))
+		if err != nil {
+			l.opts.onError(err)
+			continue
+		}
+		l.Dispatch(p)
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestListener(t *testing.T) {
+	bundle := osc.NewBundle(time.Unix(0, 0))
+	bundle.Append(message("/a", int32(2)))
+	bundle.Append(message("/b", int32(3)))
+
+	tests := []struct {
+		desc    string
+		handle  []string
+		remove  bool
+		packets []osc.Packet
+		want    []*osc.Message
+	}{
+		{
+			desc:   "calls the handler of the address",
+			handle: []string{"/a"},
+			packets: []osc.Packet{
+				message("/a", float32(0.5)),
+				message("/b", float32(1)),
+				message("/a", int32(1)),
+			},
+			want: []*osc.Message{
+				message("/a", float32(0.5)),
+				message("/a", int32(1)),
+			},
+		},
+		{
+			desc:   "calls all the handlers of the address",
+			handle: []string{"/a", "/a"},
+			packets: []osc.Packet{
+				message("/a", int32(1)),
+			},
+			want: []*osc.Message{
+				message("/a", int32(1)),
+				message("/a", int32(1)),
+			},
+		},
+		{
+			desc:    "handles the messages of bundles",
+			handle:  []string{"/a", "/b"},
+			packets: []osc.Packet{bundle},
+			want: []*osc.Message{
+				message("/a", int32(2)),
+				message("/b", int32(3)),
+			},
+		},
+		{
+			desc:   "removed handlers are not called",
+			handle: []string{"/a"},
+			remove: true,
+			packets: []osc.Packet{
+				message("/a", int32(1)),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			l, err := Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen => unexpected error: %v", err)
+			}
+
+			got := make(chan *osc.Message, 10)
+			for _, addr := range tc.handle {
+				remove := l.Handle(addr, func(msg *osc.Message) {
+					got <- msg
+				})
+				if tc.remove {
+					remove()
+				}
+			}
+
+			u := NewUDP("127.0.0.1", l.Addr().(*net.UDPAddr).Port)
+			defer u.Close()
+			for _, p := range tc.packets {
+				if err := u.Send(p); err != nil {
+					t.Fatalf("Send => unexpected error: %v", err)
+				}
+			}
+			// Packets from one sender arrive in order, the marker is handled
+			// after all the others.
+			l.Handle("/done", func(*osc.Message) { close(got) })
+			if err := u.Send(message("/done")); err != nil {
+				t.Fatalf("Send => unexpected error: %v", err)
+			}
+
+			var msgs []*osc.Message
+			timeout := time.After(5 * time.Second)
+		receive:
+			for {
+				select {
+				case msg, ok := <-got:
+					if !ok {
+						break receive
+					}
+					msgs = append(msgs, msg)
+				case <-timeout:
+					t.Fatalf("timed out waiting for the messages")
+				}
+			}
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close => unexpected error: %v", err)
+			}
+
+			if diff := pretty.Compare(tc.want, msgs); diff != "" {
+				t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListenerDispatch(t *testing.T) {
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen => unexpected error: %v", err)
+	}
+	defer l.Close()
+
+	var got []*osc.Message
+	l.Handle("/a", func(msg *osc.Message) {
+		got = append(got, msg)
+	})
+	l.Dispatch(message("/a", int32(1)))
+	l.Dispatch(message("/b", int32(2)))
+
+	want := []*osc.Message{message("/a", int32(1))}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+	}
+}
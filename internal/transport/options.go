@@ -0,0 +1,130 @@
+package transport
+
+// options.go contains configurable options for Queue, Limiter, Target and
+// Listener.
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Option is used to provide options to NewQueue(), NewLimiter(), NewTarget()
+// and Listen().
+// Options that don't apply are ignored.
+type Option interface {
+	// set sets the provided option.
+	set(*options)
+}
+
+// options stores the provided options.
+type options struct {
+	size     int
+	overflow OverflowPolicy
+	merge    MergeFunc
+	onError  func(error)
+	bundle   bool
+}
+
+// validate validates the provided options.
+func (o *options) validate() error {
+	if o.size < 1 {
+		return fmt.Errorf("invalid queue size %d, must be 1 or more", o.size)
+	}
+	if o.overflow < DropNewest || o.overflow > Coalesce {
+		return fmt.Errorf("invalid overflow policy %d", o.overflow)
+	}
+	if o.merge == nil {
+		return fmt.Errorf("invalid merge function, must not be nil")
+	}
+	if o.onError == nil {
+		return fmt.Errorf("invalid error handler, must not be nil")
+	}
+	return nil
+}
+
+// newOptions returns a new options instance.
+func newOptions() *options {
+	return &options{
+		size:     DefaultQueueSize,
+		overflow: DropOldest,
+		merge:    SumDeltas,
+		onError: func(err error) {
+			log.Printf("error sending osc packet: %v", err)
+		},
+	}
+}
+
+// option implements Option.
+type option func(*options)
+
+// set implements Option.set.
+func (o option) set(opts *options) {
+	o(opts)
+}
+
+// DefaultQueueSize is the default value for the QueueSize option.
+const DefaultQueueSize = 64
+
+// QueueSize sets the number of packets the queue holds before the overflow
+// policy applies. Must be 1 or more.
+func QueueSize(n int) Option {
+	return option(func(opts *options) {
+		opts.size = n
+	})
+}
+
+// OverflowPolicy determines what happens when a packet is sent to a full
+// queue.
+type OverflowPolicy int
+
+const (
+	// DropNewest drops the packet being sent.
+	DropNewest OverflowPolicy = iota
+	// DropOldest drops the packet that was queued first. This is the default
+	// policy.
+	DropOldest
+	// Coalesce merges the message being sent into the last queued message to
+	// the same address using the merge function. Packets that can't be
+	// merged fall back to DropOldest.
+	Coalesce
+)
+
+// Overflow sets what happens when a packet is sent to a full queue.
+func Overflow(p OverflowPolicy) Option {
+	return option(func(opts *options) {
+		opts.overflow = p
+	})
+}
+
+// MergeFunc merges the message next into the queued or pending message to the
+// same address, returning the merged message. Returns false if the messages
+// can't be merged.
+type MergeFunc func(queued, next *osc.Message) (*osc.Message, bool)
+
+// Merge sets the function used to merge messages with the Coalesce overflow
+// policy of a Queue, by a Limiter and by the queue of a Target. Defaults to
+// SumDeltas, except for a Target.
+func Merge(fn MergeFunc) Option {
+	return option(func(opts *options) {
+		opts.merge = fn
+	})
+}
+
+// OnError sets the function called with the errors of sending queued or
+// pending packets, which happens after Send returned, and with the errors of
+// receiving packets. Defaults to logging the error.
+func OnError(fn func(error)) Option {
+	return option(func(opts *options) {
+		opts.onError = fn
+	})
+}
+
+// Bundle makes a Limiter send the messages to all the addresses that are due
+// at the same time as one OSC bundle.
+func Bundle() Option {
+	return option(func(opts *options) {
+		opts.bundle = true
+	})
+}
@@ -0,0 +1,181 @@
+package transport
+
+// queue.go contains a Transport that sends asynchronously.
+
+import (
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Queue sends packets with another transport from a worker goroutine, so that
+// Send never waits on the network. The packets wait in a bounded queue, the
+// overflow policy determines what happens when it is full.
+// The worker is started when the first packet is sent.
+//
+// Implements Transport. This object is thread-safe.
+type Queue struct {
+	// t is the transport the packets are sent with.
+	t Transport
+	// opts are the provided options.
+	opts *options
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// cond signals the worker that packets were queued or the queue closed.
+	cond *sync.Cond
+	// pending are the packets waiting to be sent, oldest first.
+	pending []osc.Packet
+	started bool
+	closed  bool
+	// done is closed when the worker exits.
+	done chan struct{}
+}
+
+// NewQueue returns a new Queue that sends with the transport.
+// The queue owns the transport and closes it when it is closed.
+func NewQueue(t Transport, opts ...Option) (*Queue, error) {
+	opt := newOptions()
+	for _, o := range opts {
+		o.set(opt)
+	}
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		t:    t,
+		opts: opt,
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q, nil
+}
+
+// Send queues the packet to be sent and returns immediately.
+// Errors sending the packet are reported to the OnError function.
+// Implements Transport.Send.
+func (q *Queue) Send(p osc.Packet) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if !q.started {
+		q.started = true
+		go q.run()
+	}
+
+	if len(q.pending) < q.opts.size {
+		q.pending = append(q.pending, p)
+	} else {
+		q.overflow(p)
+	}
+	q.cond.Signal()
+	return nil
+}
+
+// Close sends the packets still in the queue, stops the worker and closes the
+// transport.
+// Implements Transport.Close.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	started := q.started
+	q.cond.Signal()
+	q.mu.Unlock()
+
+	if started {
+		<-q.done
+	}
+	return q.t.Close()
+}
+
+// overflow applies the overflow policy to the packet sent to the full queue.
+// The caller must hold q.mu.
+func (q *Queue) overflow(p osc.Packet) {
+	switch q.opts.overflow {
+	case DropNewest:
+		return
+	case Coalesce:
+		if q.coalesce(p) {
+			return
+		}
+	}
+	q.pending = append(q.pending[1:], p)
+}
+
+// coalesce merges the packet into the last queued message to the same
+// address. Returns false if the packet isn't a message or can't be merged.
+// The caller must hold q.mu.
+func (q *Queue) coalesce(p osc.Packet) bool {
+	msg, ok := p.(*osc.Message)
+	if !ok {
+		return false
+	}
+	for i := len(q.pending) - 1; i >= 0; i-- {
+		queued, ok := q.pending[i].(*osc.Message)
+		if !ok || queued.Address != msg.Address {
+			continue
+		}
+		merged, ok := q.opts.merge(queued, msg)
+		if !ok {
+			return false
+		}
+		q.pending[i] = merged
+		return true
+	}
+	return false
+}
+
+// run sends the queued packets until the queue is closed and empty.
+func (q *Queue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		p := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if err := q.t.Send(p); err != nil {
+			q.opts.onError(err)
+		}
+	}
+}
+
+// SumDeltas merges messages with a single int32 or float32 argument of the
+// same type by adding them up, e.g. relative encoder deltas.
+// Implements MergeFunc.
+func SumDeltas(queued, next *osc.Message) (*osc.Message, bool) {
+	if len(queued.Arguments) != 1 || len(next.Arguments) != 1 {
+		return nil, false
+	}
+	switch a := queued.Arguments[0].(type) {
+	case int32:
+		if b, ok := next.Arguments[0].(int32); ok {
+			return osc.NewMessage(queued.Address, a+b), true
+		}
+	case float32:
+		if b, ok := next.Arguments[0].(float32); ok {
+			return osc.NewMessage(queued.Address, a+b), true
+		}
+	}
+	return nil, false
+}
+
+// LatestValue merges messages by keeping the next one, e.g. absolute values.
+// Implements MergeFunc.
+func LatestValue(_, next *osc.Message) (*osc.Message, bool) {
+	return next, true
+}
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// blocking is a recorder whose first Send blocks until released, which holds
+// the worker of a queue so that the packets sent meanwhile stay queued.
+type blocking struct {
+	*Recorder
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+// newBlocking returns a new blocking recorder.
+func newBlocking() *blocking {
+	return &blocking{
+		Recorder: NewRecorder(),
+		entered:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+}
+
+// Send implements Transport.Send.
+func (b *blocking) Send(p osc.Packet) error {
+	b.once.Do(func() {
+		close(b.entered)
+		<-b.release
+	})
+	return b.Recorder.Send(p)
+}
+
+// failing is a transport that fails to send.
+type failing struct{}
+
+func (failing) Send(osc.Packet) error { return errors.New("network is unreachable") }
+func (failing) Close() error          { return nil }
+
+func TestQueue(t *testing.T) {
+	enc := func(d int32) *osc.Message { return message("/remote/enc/1", d) }
+	key := func(s int32) *osc.Message { return message("/remote/key/1", s) }
+
+	tests := []struct {
+		desc       string
+		opts       []Option
+		wantNewErr bool
+		// first is sent before, the packets while the worker is busy sending
+		// it.
+		first   osc.Packet
+		packets []osc.Packet
+		want    []*osc.Message
+	}{
+		{
+			desc:       "fails on zero size",
+			opts:       []Option{QueueSize(0)},
+			wantNewErr: true,
+		},
+		{
+			desc:       "fails on unknown overflow policy",
+			opts:       []Option{Overflow(Coalesce + 1)},
+			wantNewErr: true,
+		},
+		{
+			desc:       "fails on nil merge function",
+			opts:       []Option{Merge(nil)},
+			wantNewErr: true,
+		},
+		{
+			desc:    "sends all the packets in order when not full",
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), key(1), enc(3)},
+			want:    []*osc.Message{enc(1), enc(2), key(1), enc(3)},
+		},
+		{
+			desc: "drops the newest packets",
+			opts: []Option{
+				QueueSize(2),
+				Overflow(DropNewest),
+			},
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), enc(3), enc(4), enc(5)},
+			want:    []*osc.Message{enc(1), enc(2), enc(3)},
+		},
+		{
+			desc: "drops the oldest packets",
+			opts: []Option{
+				QueueSize(2),
+			},
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), enc(3), enc(4), enc(5)},
+			want:    []*osc.Message{enc(1), enc(4), enc(5)},
+		},
+		{
+			desc: "coalesces deltas to the same address",
+			opts: []Option{
+				QueueSize(2),
+				Overflow(Coalesce),
+			},
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), key(1), enc(3), enc(-1)},
+			want:    []*osc.Message{enc(1), enc(4), key(1)},
+		},
+		{
+			desc: "coalesces to the latest value",
+			opts: []Option{
+				QueueSize(1),
+				Overflow(Coalesce),
+				Merge(LatestValue),
+			},
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), enc(3), enc(4)},
+			want:    []*osc.Message{enc(1), enc(4)},
+		},
+		{
+			desc: "drops the oldest packet when it can't coalesce",
+			opts: []Option{
+				QueueSize(2),
+				Overflow(Coalesce),
+			},
+			first:   enc(1),
+			packets: []osc.Packet{enc(2), key(1), message("/remote/enc/1", float32(0.5))},
+			want:    []*osc.Message{enc(1), key(1), message("/remote/enc/1", float32(0.5))},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			b := newBlocking()
+			q, err := NewQueue(b, tc.opts...)
+			if (err != nil) != tc.wantNewErr {
+				t.Fatalf("NewQueue => unexpected error: %v, wantNewErr: %v", err, tc.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if err := q.Send(tc.first); err != nil {
+				t.Fatalf("Send => unexpected error: %v", err)
+			}
+			<-b.entered
+			for _, p := range tc.packets {
+				if err := q.Send(p); err != nil {
+					t.Fatalf("Send => unexpected error: %v", err)
+				}
+			}
+			close(b.release)
+			if err := q.Close(); err != nil {
+				t.Fatalf("Close => unexpected error: %v", err)
+			}
+
+			if diff := pretty.Compare(tc.want, b.Messages()); diff != "" {
+				t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+			}
+			if !b.Closed() {
+				t.Errorf("Close didn't close the transport")
+			}
+		})
+	}
+}
+
+func TestQueueClosed(t *testing.T) {
+	r := NewRecorder()
+	q, err := NewQueue(r)
+	if err != nil {
+		t.Fatalf("NewQueue => unexpected error: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("second Close => unexpected error: %v", err)
+	}
+	if err := q.Send(message("/a")); err != ErrClosed {
+		t.Errorf("Send => %v, want %v", err, ErrClosed)
+	}
+	if !r.Closed() {
+		t.Errorf("Close didn't close the transport")
+	}
+}
+
+func TestQueueOnError(t *testing.T) {
+	var got []string
+	q, err := NewQueue(failing{}, OnError(func(err error) {
+		got = append(got, err.Error())
+	}))
+	if err != nil {
+		t.Fatalf("NewQueue => unexpected error: %v", err)
+	}
+	if err := q.Send(message("/a")); err != nil {
+		t.Fatalf("Send => unexpected error: %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+
+	want := []string{"network is unreachable"}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("unexpected errors (-want, +got):\n%s", diff)
+	}
+}
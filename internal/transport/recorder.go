@@ -0,0 +1,97 @@
+package transport
+
+// recorder.go contains an in-memory Transport for tests.
+
+import (
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Recorder is an in-memory transport that records the packets sent with it
+// instead of sending them anywhere. Useful in tests.
+//
+// Implements Transport. This object is thread-safe.
+type Recorder struct {
+	// mu protects the fields below.
+	mu      sync.Mutex
+	packets []osc.Packet
+	closed  bool
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Send implements Transport.Send.
+func (r *Recorder) Send(p osc.Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	r.packets = append(r.packets, p)
+	return nil
+}
+
+// Close implements Transport.Close.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+// Closed asserts whether the recorder was closed.
+func (r *Recorder) Closed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.closed
+}
+
+// Packets returns the packets sent so far in the order they were sent.
+func (r *Recorder) Packets() []osc.Packet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]osc.Packet(nil), r.packets...)
+}
+
+// Messages returns the messages sent so far in the order they were sent,
+// including the messages of bundles.
+func (r *Recorder) Messages() []*osc.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var msgs []*osc.Message
+	for _, p := range r.packets {
+		msgs = appendMessages(msgs, p)
+	}
+	return msgs
+}
+
+// Reset forgets the packets sent so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.packets = nil
+}
+
+// appendMessages appends the messages of the packet to msgs.
+func appendMessages(msgs []*osc.Message, p osc.Packet) []*osc.Message {
+	switch p := p.(type) {
+	case *osc.Message:
+		msgs = append(msgs, p)
+	case *osc.Bundle:
+		msgs = append(msgs, p.Messages...)
+		for _, b := range p.Bundles {
+			msgs = appendMessages(msgs, b)
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRecorder(t *testing.T) {
+	inner := osc.NewBundle(time.Unix(0, 0))
+	inner.Append(message("/c", int32(3)))
+	bundle := osc.NewBundle(time.Unix(0, 0))
+	bundle.Append(message("/b", int32(2)))
+	bundle.Append(inner)
+
+	tests := []struct {
+		desc         string
+		packets      []osc.Packet
+		reset        bool
+		wantMessages []*osc.Message
+	}{
+		{
+			desc: "records nothing",
+		},
+		{
+			desc: "records messages in order",
+			packets: []osc.Packet{
+				message("/a", int32(1)),
+				message("/b", int32(2)),
+			},
+			wantMessages: []*osc.Message{
+				message("/a", int32(1)),
+				message("/b", int32(2)),
+			},
+		},
+		{
+			desc: "flattens bundles",
+			packets: []osc.Packet{
+				message("/a", int32(1)),
+				bundle,
+			},
+			wantMessages: []*osc.Message{
+				message("/a", int32(1)),
+				message("/b", int32(2)),
+				message("/c", int32(3)),
+			},
+		},
+		{
+			desc: "reset forgets the packets",
+			packets: []osc.Packet{
+				message("/a", int32(1)),
+			},
+			reset: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := NewRecorder()
+			for _, p := range tc.packets {
+				if err := r.Send(p); err != nil {
+					t.Fatalf("Send => unexpected error: %v", err)
+				}
+			}
+			if tc.reset {
+				r.Reset()
+			}
+
+			if diff := pretty.Compare(tc.wantMessages, r.Messages()); diff != "" {
+				t.Errorf("Messages => unexpected diff (-want, +got):\n%s", diff)
+			}
+			if !tc.reset && len(r.Packets()) != len(tc.packets) {
+				t.Errorf("Packets => got %d packets, want %d", len(r.Packets()), len(tc.packets))
+			}
+		})
+	}
+}
+
+func TestRecorderClose(t *testing.T) {
+	r := NewRecorder()
+	if r.Closed() {
+		t.Fatalf("Closed => true before Close")
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	if !r.Closed() {
+		t.Errorf("Closed => false after Close")
+	}
+	if err := r.Send(message("/a")); err != ErrClosed {
+		t.Errorf("Send => %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,150 @@
+package transport
+
+// target.go contains a Transport that shares one queue per receiver.
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// dial returns the transport a shared queue sends to the host and port with,
+// replaceable in tests.
+var dial = func(host string, port int) Transport {
+	return NewUDP(host, port)
+}
+
+// targets are the queues shared by the open Targets, by host and port.
+var targets = struct {
+	mu sync.Mutex
+	m  map[string]*shared
+}{m: map[string]*shared{}}
+
+// shared is a queue shared by the Targets to a host and port.
+type shared struct {
+	// addr is the host and port the queue sends to.
+	addr string
+	// q sends the packets of all the Targets.
+	q *Queue
+	// refs is the number of open Targets that send with the queue.
+	refs int
+
+	// mu protects merges.
+	mu sync.Mutex
+	// merges are the merge functions of the addresses sent to, set by the
+	// Target that last sent to the address.
+	merges map[string]MergeFunc
+}
+
+// merge merges the messages with the merge function of their address.
+// Implements MergeFunc.
+func (s *shared) merge(queued, next *osc.Message) (*osc.Message, bool) {
+	s.mu.Lock()
+	fn := s.merges[next.Address]
+	s.mu.Unlock()
+
+	if fn == nil {
+		return nil, false
+	}
+	return fn(queued, next)
+}
+
+// Target sends OSC packets over UDP to a host and port from a Queue that it
+// shares with all the other open Targets to the same host and port, so that
+// the widgets sending to one receiver use one worker and one connection, and
+// the host is only resolved once.
+// When the queue is full, messages are merged with the function provided to
+// the Merge option of the Target that sent them. Packets of Targets without
+// one drop the oldest packet queued. Errors sending are logged.
+//
+// Implements Transport. This object is thread-safe.
+type Target struct {
+	// s is the shared queue.
+	s *shared
+	// merge merges the messages of this Target, nil if they aren't merged.
+	merge MergeFunc
+
+	// mu protects closed.
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewTarget returns a new Target that sends to the host and port.
+// Only the Merge option applies, messages aren't merged without it.
+func NewTarget(host string, port int, opts ...Option) (*Target, error) {
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d, must be in range 1 <= port <= 65535", port)
+	}
+	opt := &options{}
+	for _, o := range opts {
+		o.set(opt)
+	}
+
+	targets.mu.Lock()
+	defer targets.mu.Unlock()
+
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+	s, ok := targets.m[addr]
+	if !ok {
+		s = &shared{
+			addr:   addr,
+			merges: map[string]MergeFunc{},
+		}
+		q, err := NewQueue(dial(host, port), Overflow(Coalesce), Merge(s.merge))
+		if err != nil {
+			return nil, err
+		}
+		s.q = q
+		targets.m[addr] = s
+	}
+	s.refs++
+	return &Target{
+		s:     s,
+		merge: opt.merge,
+	}, nil
+}
+
+// Send queues the packet to be sent and returns immediately.
+// Implements Transport.Send.
+func (t *Target) Send(p osc.Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrClosed
+	}
+	if msg, ok := p.(*osc.Message); ok {
+		t.s.mu.Lock()
+		t.s.merges[msg.Address] = t.merge
+		t.s.mu.Unlock()
+	}
+	return t.s.q.Send(p)
+}
+
+// Close closes the Target. The last Target to the host and port to be closed
+// sends the packets still queued, stops the worker and closes the connection.
+// Implements Transport.Close.
+func (t *Target) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+
+	targets.mu.Lock()
+	t.s.refs--
+	last := t.s.refs == 0
+	if last {
+		delete(targets.m, t.s.addr)
+	}
+	targets.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return t.s.q.Close()
+}
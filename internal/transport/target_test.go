@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// fakeDial makes the shared queues send with the transports returned by
+// newTransport and returns the host and port of each transport created.
+func fakeDial(t *testing.T, newTransport func() Transport) *[]string {
+	t.Helper()
+	var dialed []string
+	orig := dial
+	dial = func(host string, port int) Transport {
+		dialed = append(dialed, net.JoinHostPort(host, fmt.Sprint(port)))
+		return newTransport()
+	}
+	t.Cleanup(func() { dial = orig })
+	return &dialed
+}
+
+func TestTarget(t *testing.T) {
+	var recs []*Recorder
+	dialed := fakeDial(t, func() Transport {
+		r := NewRecorder()
+		recs = append(recs, r)
+		return r
+	})
+
+	enc1, err := NewTarget("norns.local", 10111)
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+	enc2, err := NewTarget("norns.local", 10111)
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+	other, err := NewTarget("norns.local", 57120)
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+	defer other.Close()
+
+	wantDialed := []string{"norns.local:10111", "norns.local:57120"}
+	if diff := pretty.Compare(wantDialed, *dialed); diff != "" {
+		t.Errorf("NewTarget => unexpected connections (-want, +got):\n%s", diff)
+	}
+
+	for _, s := range []struct {
+		t   *Target
+		msg *osc.Message
+	}{
+		{enc1, message("/remote/enc/1", int32(1))},
+		{enc2, message("/remote/enc/2", int32(-1))},
+		{enc1, message("/remote/enc/1", int32(2))},
+	} {
+		if err := s.t.Send(s.msg); err != nil {
+			t.Fatalf("Send => unexpected error: %v", err)
+		}
+	}
+	if err := enc1.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	if recs[0].Closed() {
+		t.Errorf("Close => closed the connection still used by another target")
+	}
+	if err := enc1.Send(message("/remote/enc/1", int32(3))); err != ErrClosed {
+		t.Errorf("Send => %v, want %v", err, ErrClosed)
+	}
+	if err := enc2.Send(message("/remote/enc/2", int32(-2))); err != nil {
+		t.Fatalf("Send => unexpected error: %v", err)
+	}
+	if err := enc2.Close(); err != nil {
+		t.Fatalf("Close => unexpected error: %v", err)
+	}
+	if !recs[0].Closed() {
+		t.Errorf("Close => didn't close the connection once all the targets were closed")
+	}
+
+	want := []*osc.Message{
+		message("/remote/enc/1", int32(1)),
+		message("/remote/enc/2", int32(-1)),
+		message("/remote/enc/1", int32(2)),
+		message("/remote/enc/2", int32(-2)),
+	}
+	if diff := pretty.Compare(want, recs[0].Messages()); diff != "" {
+		t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+	}
+
+	// A target opened after all the others were closed connects again.
+	again, err := NewTarget("norns.local", 10111)
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+	defer again.Close()
+	if got, want := len(*dialed), 3; got != want {
+		t.Errorf("NewTarget => made %d connections, want %d", got, want)
+	}
+}
+
+func TestTargetMerge(t *testing.T) {
+	b := newBlocking()
+	fakeDial(t, func() Transport { return b })
+
+	enc, err := NewTarget("norns.local", 10111, Merge(SumDeltas))
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+	key, err := NewTarget("norns.local", 10111)
+	if err != nil {
+		t.Fatalf("NewTarget => unexpected error: %v", err)
+	}
+
+	send := func(t *testing.T, tr *Target, msg *osc.Message) {
+		t.Helper()
+		if err := tr.Send(msg); err != nil {
+			t.Fatalf("Send => unexpected error: %v", err)
+		}
+	}
+	// The worker sends the first press while the others fill the queue.
+	send(t, key, message("/remote/key/1", int32(1)))
+	<-b.entered
+	send(t, key, message("/remote/key/1", int32(0)))
+	for i := 1; i < DefaultQueueSize; i++ {
+		send(t, enc, message("/remote/enc/1", int32(1)))
+	}
+	// Merged into the last delta.
+	send(t, enc, message("/remote/enc/1", int32(1)))
+	// Not merged, drops the oldest packet.
+	send(t, key, message("/remote/key/1", int32(1)))
+	close(b.release)
+	for _, tr := range []*Target{enc, key} {
+		if err := tr.Close(); err != nil {
+			t.Fatalf("Close => unexpected error: %v", err)
+		}
+	}
+
+	want := []*osc.Message{message("/remote/key/1", int32(1))}
+	for i := 2; i < DefaultQueueSize; i++ {
+		want = append(want, message("/remote/enc/1", int32(1)))
+	}
+	want = append(want,
+		message("/remote/enc/1", int32(2)),
+		message("/remote/key/1", int32(1)),
+	)
+	if diff := pretty.Compare(want, b.Messages()); diff != "" {
+		t.Errorf("unexpected messages (-want, +got):\n%s", diff)
+	}
+}
+
+func TestNewTargetFails(t *testing.T) {
+	if _, err := NewTarget("norns.local", 0); err == nil {
+		t.Errorf("NewTarget => nil error, want an error for port 0")
+	}
+}
@@ -0,0 +1,86 @@
+// Package transport sends OSC packets to their receivers.
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Transport sends OSC packets, either messages or bundles.
+// Implementations must be safe for concurrent use.
+type Transport interface {
+	// Send sends the packet.
+	Send(p osc.Packet) error
+	// Close releases the resources held by the transport. Packets can't be
+	// sent once the transport is closed.
+	Close() error
+}
+
+// ErrClosed is returned when sending with a closed transport.
+var ErrClosed = errors.New("transport is closed")
+
+// UDP sends OSC packets over UDP to a single host and port, reusing one
+// connection for all the packets.
+//
+// Implements Transport. This object is thread-safe.
+type UDP struct {
+	// addr is the host and port packets are sent to.
+	addr string
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// conn is the connection, nil until the first packet is sent.
+	conn   net.Conn
+	closed bool
+}
+
+// NewUDP returns a new UDP transport that sends to the host and port.
+// The connection is established when the first packet is sent.
+func NewUDP(host string, port int) *UDP {
+	return &UDP{
+		addr: net.JoinHostPort(host, fmt.Sprint(port)),
+	}
+}
+
+// Send implements Transport.Send.
+func (u *UDP) Send(p osc.Packet) error {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closed {
+		return ErrClosed
+	}
+	if u.conn == nil {
+		conn, err := net.Dial("udp", u.addr)
+		if err != nil {
+			return err
+		}
+		u.conn = conn
+	}
+	_, err = u.conn.Write(data)
+	return err
+}
+
+// Close implements Transport.Close.
+func (u *UDP) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	if u.conn == nil {
+		return nil
+	}
+	return u.conn.Close()
+}
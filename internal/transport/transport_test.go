@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// listen returns a connection listening on a random local UDP port.
+func listen(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket => unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receive returns the packets received until no more arrive for a short
+// while.
+func receive(t *testing.T, conn net.PacketConn) []osc.Packet {
+	t.Helper()
+	var got []osc.Packet
+	buf := make([]byte, 1024)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+			t.Fatalf("SetReadDeadline => unexpected error: %v", err)
+		}
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return got
+		}
+		p, err := osc.ParsePacket(string(buf[:n]))
+		if err != nil {
+			t.Fatalf("osc.ParsePacket => unexpected error: %v", err)
+		}
+		got = append(got, p)
+	}
+}
+
+// message returns a new message to the address with the arguments.
+func message(addr string, args ...interface{}) *osc.Message {
+	return osc.NewMessage(addr, args...)
+}
+
+func TestUDP(t *testing.T) {
+	bundle := osc.NewBundle(time.Unix(0, 0))
+	bundle.Append(message("/remote/enc/1", int32(1)))
+	bundle.Append(message("/remote/enc/2", int32(-1)))
+
+	tests := []struct {
+		desc    string
+		packets []osc.Packet
+		close   bool
+		want    []osc.Packet
+		wantErr error
+	}{
+		{
+			desc: "sends messages over one connection",
+			packets: []osc.Packet{
+				message("/remote/enc/1", int32(1)),
+				message("/remote/key/2", int32(0)),
+			},
+			want: []osc.Packet{
+				message("/remote/enc/1", int32(1)),
+				message("/remote/key/2", int32(0)),
+			},
+		},
+		{
+			desc:    "sends bundles",
+			packets: []osc.Packet{bundle},
+			want:    []osc.Packet{bundle},
+		},
+		{
+			desc:  "fails to send once closed",
+			close: true,
+			packets: []osc.Packet{
+				message("/remote/enc/1", int32(1)),
+			},
+			wantErr: ErrClosed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			conn := listen(t)
+			u := NewUDP("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+			defer u.Close()
+			if tc.close {
+				if err := u.Close(); err != nil {
+					t.Fatalf("Close => unexpected error: %v", err)
+				}
+			}
+
+			for _, p := range tc.packets {
+				if err := u.Send(p); !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Send => unexpected error: %v, want: %v", err, tc.wantErr)
+				}
+			}
+
+			got := receive(t, conn)
+			if diff := pretty.Compare(tc.want, got); diff != "" {
+				t.Errorf("unexpected packets (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}